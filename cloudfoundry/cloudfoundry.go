@@ -0,0 +1,128 @@
+// Package cloudfoundry reads the VCAP_SERVICES and VCAP_APPLICATION environment
+// variables Cloud Foundry provides to applications, including the Spring Cloud
+// Services config server and service registry bindings.
+package cloudfoundry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	EnvServices    = "VCAP_SERVICES"
+	EnvApplication = "VCAP_APPLICATION"
+)
+
+// Labels and tags identifying the Spring Cloud Services offerings.  The dashed
+// labels are used by SCS 1.x and the dotted ones by later versions
+var (
+	ConfigServerLabels    = []string{"p-config-server", "p.config-server"}
+	ConfigServerTag       = "configuration"
+	ServiceRegistryLabels = []string{"p-service-registry", "p.service-registry"}
+	ServiceRegistryTag    = "eureka"
+)
+
+// Credential keys of Spring Cloud Services bindings
+const (
+	CredentialURI            = "uri"
+	CredentialClientId       = "client_id"
+	CredentialClientSecret   = "client_secret"
+	CredentialAccessTokenURI = "access_token_uri"
+)
+
+// Service is a service instance bound to the application
+type Service struct {
+	Name        string                 `json:"name"`
+	Label       string                 `json:"label"`
+	Plan        string                 `json:"plan"`
+	Tags        []string               `json:"tags"`
+	Credentials map[string]interface{} `json:"credentials"`
+}
+
+// Services are the bound service instances keyed by service label
+type Services map[string][]*Service
+
+// Application describes the running application instance
+type Application struct {
+	ApplicationId   string   `json:"application_id"`
+	ApplicationName string   `json:"application_name"`
+	ApplicationUris []string `json:"application_uris"`
+	InstanceId      string   `json:"instance_id"`
+	InstanceIndex   int      `json:"instance_index"`
+	SpaceName       string   `json:"space_name"`
+}
+
+// ReadServices parses VCAP_SERVICES.  Nil is returned when it is not set
+func ReadServices() (Services, error) {
+	v := os.Getenv(EnvServices)
+	if v == "" {
+		return nil, nil
+	}
+
+	services := Services{}
+	if err := json.Unmarshal([]byte(v), &services); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %s", EnvServices, err.Error())
+	}
+	return services, nil
+}
+
+// ReadApplication parses VCAP_APPLICATION.  Nil is returned when it is not set
+func ReadApplication() (*Application, error) {
+	v := os.Getenv(EnvApplication)
+	if v == "" {
+		return nil, nil
+	}
+
+	app := &Application{}
+	if err := json.Unmarshal([]byte(v), app); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %s", EnvApplication, err.Error())
+	}
+	return app, nil
+}
+
+// Find returns the first service with one of labels or, failing that, the first
+// service tagged with tag.  Nil is returned when no service matches
+func (s Services) Find(labels []string, tag string) *Service {
+	for _, label := range labels {
+		if services := s[label]; len(services) > 0 {
+			return services[0]
+		}
+	}
+	keys := make([]string, 0, len(s))
+	for label := range s {
+		keys = append(keys, label)
+	}
+	sort.Strings(keys)
+
+	for _, label := range keys {
+		for _, svc := range s[label] {
+			for _, t := range svc.Tags {
+				if strings.EqualFold(t, tag) {
+					return svc
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ConfigServer returns the bound Spring Cloud Services config server
+func (s Services) ConfigServer() *Service {
+	return s.Find(ConfigServerLabels, ConfigServerTag)
+}
+
+// ServiceRegistry returns the bound Spring Cloud Services service registry
+func (s Services) ServiceRegistry() *Service {
+	return s.Find(ServiceRegistryLabels, ServiceRegistryTag)
+}
+
+// Credential returns the string credential name or an empty string
+func (s *Service) Credential(name string) string {
+	if v, ok := s.Credentials[name].(string); ok {
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package cloudfoundry
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testServices = `{
+  "p.config-server": [{
+    "name": "config-server",
+    "label": "p.config-server",
+    "plan": "standard",
+    "tags": ["configuration", "spring-cloud"],
+    "credentials": {
+      "uri": "https://config-server.apps.example.com",
+      "client_id": "config-client",
+      "client_secret": "s3cret",
+      "access_token_uri": "https://uaa.example.com/oauth/token"
+    }
+  }],
+  "user-provided": [{
+    "name": "registry",
+    "label": "user-provided",
+    "tags": ["Eureka"],
+    "credentials": {"uri": "https://registry.apps.example.com"}
+  }]
+}`
+
+func TestReadServices(t *testing.T) {
+	t.Setenv(EnvServices, testServices)
+	services, err := ReadServices()
+	assert.NoError(t, err)
+
+	cs := services.ConfigServer()
+	if assert.NotNil(t, cs) {
+		assert.Equal(t, "config-server", cs.Name)
+		assert.Equal(t, "config-client", cs.Credential(CredentialClientId))
+		assert.Equal(t, "", cs.Credential("missing"))
+	}
+
+	// found by tag when not bound through the Spring Cloud Services label
+	sr := services.ServiceRegistry()
+	if assert.NotNil(t, sr) {
+		assert.Equal(t, "https://registry.apps.example.com", sr.Credential(CredentialURI))
+	}
+}
+
+func TestReadServicesUnset(t *testing.T) {
+	t.Setenv(EnvServices, "")
+	services, err := ReadServices()
+	assert.NoError(t, err)
+	assert.Nil(t, services.ConfigServer())
+
+	t.Setenv(EnvServices, "{")
+	_, err = ReadServices()
+	assert.Error(t, err)
+}
+
+func TestReadApplication(t *testing.T) {
+	t.Setenv(EnvApplication, `{"application_name": "myapp", "application_uris": ["myapp.apps.example.com"], "instance_index": 2}`)
+	app, err := ReadApplication()
+	assert.NoError(t, err)
+	assert.Equal(t, "myapp", app.ApplicationName)
+	assert.Equal(t, []string{"myapp.apps.example.com"}, app.ApplicationUris)
+	assert.Equal(t, 2, app.InstanceIndex)
+
+	t.Setenv(EnvApplication, "")
+	app, err = ReadApplication()
+	assert.NoError(t, err)
+	assert.Nil(t, app)
+}
@@ -0,0 +1,272 @@
+// springcloud-exec fetches an application's configuration from a Spring Cloud
+// Config server and runs a child process with the properties injected as
+// environment variables (ex. datasource.mysql.user becomes DATASOURCE_MYSQL_USER).
+//
+// Usage:
+//
+//	springcloud-exec [flags] -- command [args...]
+//
+// Signals received by springcloud-exec are forwarded to the child and the exit
+// code of the child is returned.  When -poll is set the configuration is watched
+// and the child is restarted (-on-change=restart) or signalled (-on-change=signal)
+// whenever the properties change.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/ContainX/go-springcloud/internal/cli"
+	"github.com/ContainX/go-utils/logger"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	onChangeRestart = "restart"
+	onChangeSignal  = "signal"
+	onChangeNone    = "none"
+)
+
+var log = logger.GetLogger("springcloud-exec")
+
+// forwardSignals are relayed from this process to the child
+var forwardSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2}
+
+type options struct {
+	client       *cli.ClientFlags
+	pristine     bool
+	poll         time.Duration
+	onChange     string
+	reloadSignal os.Signal
+	killTimeout  time.Duration
+	args         []string
+}
+
+// supervisor runs the child process and restarts or signals it on configuration changes
+type supervisor struct {
+	sync.Mutex
+	opts     *options
+	args     []string
+	cmd      *exec.Cmd
+	exited   chan error
+	restarts chan config.Properties
+}
+
+func main() {
+	os.Exit(runMain(os.Args[1:]))
+}
+
+// runMain runs springcloud-exec with the command line arguments and returns the
+// process exit code
+func runMain(arguments []string) int {
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	opts, err := parseFlags(fs, arguments)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		fs.Usage()
+		return 2
+	}
+
+	client, err := opts.client.NewClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	if _, err := client.Refresh(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching configuration: %s\n", err.Error())
+		return 1
+	}
+
+	s := &supervisor{opts: opts, args: opts.args, restarts: make(chan config.Properties, 1)}
+	if err := s.start(client.Properties()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting %s: %s\n", opts.args[0], err.Error())
+		return 1
+	}
+
+	if opts.poll > 0 && opts.onChange != onChangeNone {
+		client.OnRefresh(func(old, new config.Properties) {
+			if old == nil {
+				return
+			}
+			select {
+			case <-s.restarts:
+			default:
+			}
+			s.restarts <- new
+		})
+		stop := make(chan struct{})
+		defer close(stop)
+		go client.Watch(opts.poll, stop)
+	}
+
+	return s.run()
+}
+
+// parseFlags parses and validates the flags and the command to run
+func parseFlags(fs *flag.FlagSet, arguments []string) (*options, error) {
+	opts := &options{}
+	opts.client = cli.AddClientFlags(fs)
+	fs.BoolVar(&opts.pristine, "pristine", false, "Only pass the configuration properties to the child, not the current environment")
+	fs.DurationVar(&opts.poll, "poll", 0, "Interval to poll for configuration changes (0 disables watching)")
+	fs.StringVar(&opts.onChange, "on-change", onChangeRestart, "Action when the configuration changes: restart, signal or none")
+	reloadSignal := fs.String("reload-signal", "SIGHUP", "Signal sent to the child with -on-change=signal")
+	fs.DurationVar(&opts.killTimeout, "kill-timeout", 30*time.Second, "Time to wait for the child to exit on restart before killing it")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] -- command [args...]\n\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(arguments); err != nil {
+		return nil, err
+	}
+
+	switch opts.onChange {
+	case onChangeRestart, onChangeSignal, onChangeNone:
+	default:
+		return nil, fmt.Errorf("Unknown -on-change action %q", opts.onChange)
+	}
+
+	sig, err := parseSignal(*reloadSignal)
+	if err != nil {
+		return nil, err
+	}
+	opts.reloadSignal = sig
+
+	opts.args = fs.Args()
+	if len(opts.args) == 0 {
+		return nil, fmt.Errorf("A command to run must be declared")
+	}
+	return opts, nil
+}
+
+// environ builds the child environment from the properties
+func (s *supervisor) environ(p config.Properties) ([]string, error) {
+	env, err := config.Environ(p)
+	if err != nil || s.opts.pristine {
+		return env, err
+	}
+	return append(os.Environ(), env...), nil
+}
+
+func (s *supervisor) start(p config.Properties) error {
+	s.Lock()
+	defer s.Unlock()
+
+	env, err := s.environ(p)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(s.args[0], s.args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	s.cmd = cmd
+	s.exited = exited
+	return nil
+}
+
+func (s *supervisor) signal(sig os.Signal) {
+	s.Lock()
+	defer s.Unlock()
+	if s.cmd != nil && s.cmd.Process != nil {
+		s.cmd.Process.Signal(sig)
+	}
+}
+
+// restart stops the current child, waiting up to the kill timeout, and starts a
+// new one with the updated properties
+func (s *supervisor) restart(p config.Properties) error {
+	log.Info("Configuration changed, restarting child process")
+	s.signal(syscall.SIGTERM)
+	select {
+	case <-s.exited:
+	case <-time.After(s.opts.killTimeout):
+		log.Warning("Child did not exit in time, killing it")
+		s.signal(syscall.SIGKILL)
+		<-s.exited
+	}
+	return s.start(p)
+}
+
+// handleChange signals or restarts the child, following -on-change, after the
+// properties changed to p
+func (s *supervisor) handleChange(p config.Properties) error {
+	if s.opts.onChange == onChangeSignal {
+		log.Infof("Configuration changed, sending %s to child process", s.opts.reloadSignal)
+		s.signal(s.opts.reloadSignal)
+		return nil
+	}
+	return s.restart(p)
+}
+
+// run forwards signals and handles configuration changes until the child exits.
+// The exit code of the child is returned
+func (s *supervisor) run() int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardSignals...)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case sig := <-signals:
+			s.signal(sig)
+		case p := <-s.restarts:
+			if err := s.handleChange(p); err != nil {
+				log.Errorf("Error restarting child process: %s", err.Error())
+				return 1
+			}
+		case err := <-s.exited:
+			return exitCode(err)
+		}
+	}
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if ee, ok := err.(*exec.ExitError); ok {
+		if status, ok := ee.Sys().(syscall.WaitStatus); ok {
+			if status.Signaled() {
+				return 128 + int(status.Signal())
+			}
+			return status.ExitStatus()
+		}
+	}
+	return 1
+}
+
+// parseSignal converts a name such as SIGHUP or HUP into a signal
+func parseSignal(name string) (os.Signal, error) {
+	switch strings.TrimPrefix(strings.ToUpper(name), "SIG") {
+	case "HUP":
+		return syscall.SIGHUP, nil
+	case "INT":
+		return syscall.SIGINT, nil
+	case "TERM":
+		return syscall.SIGTERM, nil
+	case "QUIT":
+		return syscall.SIGQUIT, nil
+	case "USR1":
+		return syscall.SIGUSR1, nil
+	case "USR2":
+		return syscall.SIGUSR2, nil
+	}
+	return nil, fmt.Errorf("Unknown signal %q", name)
+}
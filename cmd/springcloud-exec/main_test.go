@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func testFlags(args ...string) (*options, error) {
+	fs := flag.NewFlagSet("springcloud-exec", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return parseFlags(fs, args)
+}
+
+func TestParseFlags(t *testing.T) {
+	opts, err := testFlags("-name", "myapp", "-on-change", "signal", "-reload-signal", "usr1", "--", "app", "-v")
+	assert.NoError(t, err)
+	assert.Equal(t, "myapp", opts.client.Bootstrap.Name)
+	assert.Equal(t, onChangeSignal, opts.onChange)
+	assert.Equal(t, syscall.SIGUSR1, opts.reloadSignal)
+	assert.Equal(t, []string{"app", "-v"}, opts.args)
+
+	opts, err = testFlags("app")
+	assert.NoError(t, err)
+	assert.Equal(t, onChangeRestart, opts.onChange)
+	assert.Equal(t, syscall.SIGHUP, opts.reloadSignal)
+
+	_, err = testFlags("-on-change", "reboot", "app")
+	assert.EqualError(t, err, `Unknown -on-change action "reboot"`)
+
+	_, err = testFlags("-reload-signal", "SIGFOO", "app")
+	assert.EqualError(t, err, `Unknown signal "SIGFOO"`)
+
+	_, err = testFlags("-name", "myapp")
+	assert.Error(t, err)
+}
+
+// startChild starts a supervised shell which writes $FOO to $OUT and then waits
+func startChild(t *testing.T, onChange string, p config.Properties) *supervisor {
+	opts := &options{onChange: onChange, reloadSignal: syscall.SIGUSR1, killTimeout: 5 * time.Second}
+	s := &supervisor{opts: opts, args: []string{"sh", "-c", `echo "$FOO" > "$OUT"; exec sleep 30`}}
+	assert.NoError(t, s.start(p))
+	t.Cleanup(func() { s.signal(syscall.SIGKILL) })
+	return s
+}
+
+// waitForFile returns the content of filename once the child has written it
+func waitForFile(t *testing.T, filename string) string {
+	for i := 0; i < 100; i++ {
+		if b, err := ioutil.ReadFile(filename); err == nil && len(b) > 0 {
+			return string(b)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("%s was not written", filename)
+	return ""
+}
+
+func TestHandleChangeRestart(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	s := startChild(t, onChangeRestart, config.Properties{"foo": "old", "out": out})
+	assert.Equal(t, "old\n", waitForFile(t, out))
+	pid := s.cmd.Process.Pid
+
+	os.Remove(out)
+	assert.NoError(t, s.handleChange(config.Properties{"foo": "new", "out": out}))
+	assert.Equal(t, "new\n", waitForFile(t, out))
+	assert.NotEqual(t, pid, s.cmd.Process.Pid)
+}
+
+func TestHandleChangeSignal(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	s := startChild(t, onChangeSignal, config.Properties{"foo": "old", "out": out})
+	waitForFile(t, out)
+	pid := s.cmd.Process.Pid
+
+	// the child is signalled rather than restarted and, without a handler, exits
+	assert.NoError(t, s.handleChange(config.Properties{"foo": "new", "out": out}))
+	select {
+	case err := <-s.exited:
+		assert.Equal(t, 128+int(syscall.SIGUSR1), exitCode(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the child to receive the reload signal")
+	}
+	assert.Equal(t, pid, s.cmd.Process.Pid)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/ContainX/go-springcloud/internal/cli"
+	"io"
+	"os"
+	"strings"
+)
+
+// diffCmd prints the properties added, removed and changed between two profiles
+// and/or labels of an application.  The exit code is 1 when they differ
+func diffCmd(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	client := cli.AddClientFlags(fs)
+	showSecrets := fs.Bool("show-secrets", false, "Print sensitive values instead of masking them")
+	asJSON := fs.Bool("json", false, "Print the changes as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: springcloud config diff [flags] <from> <to>")
+		fmt.Fprintln(os.Stderr, "\n<from> and <to> are [profile][@label], ex. staging prod or prod@v1.0 prod@v1.1")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	from, err := client.NewClientFor(parseTarget(fs.Arg(0)))
+	if err != nil {
+		return fail(err)
+	}
+	to, err := client.NewClientFor(parseTarget(fs.Arg(1)))
+	if err != nil {
+		return fail(err)
+	}
+
+	changes, err := config.DiffClients(from, to)
+	if err != nil {
+		return fail(err)
+	}
+	if !*showSecrets {
+		changes = config.RedactChanges(changes, config.DefaultSanitizer)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(changes)
+	} else {
+		writeChanges(os.Stdout, changes)
+	}
+
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// parseTarget splits [profile][@label] into its profile and label
+func parseTarget(target string) (profile, label string) {
+	if i := strings.LastIndex(target, "@"); i >= 0 {
+		return target[:i], target[i+1:]
+	}
+	return target, ""
+}
+
+func writeChanges(out io.Writer, changes []config.PropertyChange) {
+	for _, c := range changes {
+		switch c.Type {
+		case config.Added:
+			fmt.Fprintf(out, "+ %s = %s\n", c.Key, c.New)
+		case config.Removed:
+			fmt.Fprintf(out, "- %s = %s\n", c.Key, c.Old)
+		default:
+			fmt.Fprintf(out, "~ %s: %s -> %s\n", c.Key, c.Old, c.New)
+		}
+	}
+}
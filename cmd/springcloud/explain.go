@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/ContainX/go-springcloud/internal/cli"
+	"github.com/ContainX/go-utils/encoding"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// explainCmd prints the winning value of a property, where it came from and every
+// value it shadows
+func explainCmd(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	client := cli.AddClientFlags(fs)
+	defaults := fs.String("defaults", "", "File (json or yml) holding the application default properties")
+	showSecrets := fs.Bool("show-secrets", false, "Print sensitive values instead of masking them")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: springcloud config explain [flags] <key> [--key=value...]")
+		fmt.Fprintln(os.Stderr, "\nArguments after the key are treated as the application's command line")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+	key := fs.Arg(0)
+
+	c, err := client.NewClient()
+	if err != nil {
+		return fail(err)
+	}
+
+	local := config.LocalSources{Args: fs.Args()[1:]}
+	if *defaults != "" {
+		if local.Defaults, err = readProperties(*defaults); err != nil {
+			return fail(err)
+		}
+	}
+
+	props, err := c.FetchWithOrigins(local)
+	if err != nil {
+		return fail(err)
+	}
+
+	p, ok := props[key]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Property %s is not defined\n", key)
+		return 1
+	}
+	if !*showSecrets {
+		p = redactOrigins(p)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(p)
+		return 0
+	}
+	writeExplain(os.Stdout, p)
+	return 0
+}
+
+func writeExplain(out io.Writer, p *config.OriginProperty) {
+	fmt.Fprintf(out, "%s = %s\n  from %s\n", p.Key, p.Value, p.Origin)
+	if len(p.Shadowed) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "\nShadowed values (highest precedence first):")
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, s := range p.Shadowed {
+		fmt.Fprintf(w, "  %s\t%s\n", s.Value, s.Origin)
+	}
+	w.Flush()
+}
+
+// redactOrigins masks every value of p if its key is sensitive
+func redactOrigins(p *config.OriginProperty) *config.OriginProperty {
+	r := *p
+	r.Value = config.DefaultSanitizer.Sanitize(p.Key, p.Value)
+	r.Shadowed = nil
+	for _, s := range p.Shadowed {
+		s.Value = config.DefaultSanitizer.Sanitize(p.Key, s.Value)
+		r.Shadowed = append(r.Shadowed, s)
+	}
+	return &r
+}
+
+// readProperties reads a json or yml file as flattened properties
+func readProperties(filename string) (map[string]string, error) {
+	enc, err := encoding.NewEncoderFromFileExt(filename)
+	if err != nil {
+		return nil, err
+	}
+	tree := map[string]interface{}{}
+	if err := enc.UnMarshalFile(filename, &tree); err != nil {
+		return nil, err
+	}
+	return config.Flatten(tree), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config/gen"
+	"github.com/ContainX/go-springcloud/internal/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// genCmd generates Go structs mirroring an application's configuration.  It is
+// intended to be run from a go:generate directive, for example:
+//
+//	//go:generate springcloud config gen -name myapp -package settings -o config_gen.go
+func genCmd(args []string) int {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	client := cli.AddClientFlags(fs)
+	opts := gen.Options{}
+	fs.StringVar(&opts.Package, "package", os.Getenv("GOPACKAGE"), "Package of the generated file (default $GOPACKAGE)")
+	fs.StringVar(&opts.TypeName, "type", gen.TypeNameDefault, "Name of the root struct")
+	input := fs.String("in", "", "Local configuration file (json or yml) to generate from instead of fetching")
+	output := fs.String("o", "", "File to write the generated source to (default stdout)")
+	fs.Parse(args)
+
+	var tree map[string]interface{}
+	if *input != "" {
+		content, err := ioutil.ReadFile(*input)
+		if err != nil {
+			return fail(err)
+		}
+		switch strings.ToLower(filepath.Ext(*input)) {
+		case ".json":
+			tree, err = gen.DecodeJSON(content)
+		case ".yml", ".yaml":
+			tree, err = gen.DecodeYAML(content)
+		default:
+			err = fmt.Errorf("Unsupported file extension for %s, expected json or yml", *input)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		opts.Source = *input
+	} else {
+		c, err := client.NewClient()
+		if err != nil {
+			return fail(err)
+		}
+		content, err := c.FetchAsJSON()
+		if err != nil {
+			return fail(err)
+		}
+		if tree, err = gen.DecodeJSON([]byte(content)); err != nil {
+			return fail(err)
+		}
+		b := c.Bootstrap()
+		opts.Source = fmt.Sprintf("%s-%s (%s)", b.Name, b.Profile, b.Label)
+	}
+
+	src, err := gen.Generate(tree, opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(src)
+		return 0
+	}
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		return fail(err)
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config/kube"
+	"github.com/ContainX/go-springcloud/internal/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// kubeCmd renders an application's configuration as a Kubernetes ConfigMap and Secret
+func kubeCmd(args []string) int {
+	fs := flag.NewFlagSet("kube", flag.ExitOnError)
+	client := cli.AddClientFlags(fs)
+	opts := kube.Options{}
+	fs.StringVar(&opts.Name, "configmap-name", "", "Name of the ConfigMap (default the application name)")
+	fs.StringVar(&opts.SecretName, "secret-name", "", "Name of the Secret (default {configmap-name}-secrets)")
+	fs.StringVar(&opts.Namespace, "namespace", "", "Namespace of the manifests")
+	secretKeys := fs.String("secret-keys", "", "Comma-separated property keys always placed in the Secret")
+	keys := fs.String("keys", "env", "Data key format: env (DATASOURCE_URL) or property (datasource.url)")
+	output := fs.String("o", "", "File to write the manifests to (default stdout)")
+	fs.Parse(args)
+
+	switch *keys {
+	case "env":
+		opts.KeyFormat = kube.KeyEnv
+	case "property":
+		opts.KeyFormat = kube.KeyProperty
+	default:
+		return fail(fmt.Errorf("Unknown key format %q", *keys))
+	}
+
+	if *secretKeys != "" {
+		opts.SecretKeys = strings.Split(*secretKeys, ",")
+	}
+
+	c, err := client.NewClient()
+	if err != nil {
+		return fail(err)
+	}
+	if opts.Name == "" {
+		opts.Name = c.Bootstrap().Name
+	}
+
+	// rendered in full first so a failure leaves an existing output file untouched
+	b := &bytes.Buffer{}
+	if err := kube.Generate(b, c, opts); err != nil {
+		return fail(err)
+	}
+	if *output == "" {
+		os.Stdout.Write(b.Bytes())
+		return 0
+	}
+	if err := writeAtomic(*output, b.Bytes()); err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+// writeAtomic writes data, readable only by the owner since it may hold secrets, to
+// a temporary file beside filename and renames it into place
+func writeAtomic(filename string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/ContainX/go-springcloud/internal/cli"
+	"os"
+	"text/tabwriter"
+)
+
+// lintCmd reports problems with an application's configuration.  The exit code is 1
+// when a finding at or above the -fail-on severity is reported
+func lintCmd(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	client := cli.AddClientFlags(fs)
+	failOn := fs.String("fail-on", "warning", "Lowest severity (info, warning, error) which fails the lint")
+	asJSON := fs.Bool("json", false, "Print the findings as JSON")
+	fs.Parse(args)
+
+	threshold, err := config.ParseSeverity(*failOn)
+	if err != nil {
+		return fail(err)
+	}
+
+	c, err := client.NewClient()
+	if err != nil {
+		return fail(err)
+	}
+
+	findings, err := config.Lint(c)
+	if err != nil {
+		return fail(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(findings)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, f := range findings {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Severity, f.Rule, f.Key, f.Message)
+		}
+		w.Flush()
+		fmt.Fprintf(os.Stdout, "%d finding(s)\n", len(findings))
+	}
+
+	for _, f := range findings {
+		if f.Severity >= threshold {
+			return 1
+		}
+	}
+	return 0
+}
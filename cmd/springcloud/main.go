@@ -0,0 +1,59 @@
+// springcloud is a command line tool for working with Spring Cloud services.
+//
+// Usage:
+//
+//	springcloud config <command> [flags] [args...]
+//
+// Run a command with -h for its flags.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command runs with its arguments and returns the process exit code
+type command func(args []string) int
+
+var configCommands = map[string]command{
+	"diff":    diffCmd,
+	"explain": explainCmd,
+	"gen":     genCmd,
+	"kube":    kubeCmd,
+	"lint":    lintCmd,
+}
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "config" {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := configCommands[os.Args[2]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(cmd(os.Args[3:]))
+}
+
+func usage() {
+	names := []string{}
+	for name := range configCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: springcloud config <command> [flags] [args...]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  "+name)
+	}
+}
+
+// fail prints err and returns the exit code for errors
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+	return 2
+}
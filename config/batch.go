@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// BatchWorkersDefault is the number of concurrent fetches when none is specified
+const BatchWorkersDefault = 8
+
+// BatchRequest identifies a configuration to fetch.  An empty Profile or Label
+// uses the value of the batch bootstrap, with CONFIG_PROFILE taking precedence
+// over the bootstrap profile
+type BatchRequest struct {
+	Name    string `json:"name"`
+	Profile string `json:"profile,omitempty"`
+	Label   string `json:"label,omitempty"`
+}
+
+// BatchResult is the outcome of a single BatchRequest.  Err is encoded as the
+// "error" message in JSON
+type BatchResult struct {
+	Request    BatchRequest `json:"request"`
+	Properties Properties   `json:"properties,omitempty"`
+	Err        error        `json:"-"`
+}
+
+func (r BatchResult) MarshalJSON() ([]byte, error) {
+	type result BatchResult
+	v := struct {
+		result
+		Error string `json:"error,omitempty"`
+	}{result: result(r)}
+	if r.Err != nil {
+		v.Error = r.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// Batch fetches the configuration of many applications from the same server
+// concurrently, sharing the server location, credentials and connections
+type Batch struct {
+	// Workers bounds the number of concurrent fetches (default 8).
+	Workers int
+
+	client *client
+}
+
+// NewBatch creates a Batch using the server settings of b.  The bootstrap Name is
+// not required
+func NewBatch(b Bootstrap) (*Batch, error) {
+	if err := b.populateFromCloudFoundry(); err != nil {
+		return nil, err
+	}
+	c, err := newClient(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Batch{Workers: BatchWorkersDefault, client: c}, nil
+}
+
+// Fetch fetches the properties for every request.  Results are returned in the order
+// of requests and a failure of one request does not affect the others
+func (b *Batch) Fetch(requests []BatchRequest) []*BatchResult {
+	results := make([]*BatchResult, len(requests))
+	work := make(chan int)
+
+	workers := b.Workers
+	if workers <= 0 {
+		workers = BatchWorkersDefault
+	}
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i] = b.fetch(requests[i])
+			}
+		}()
+	}
+
+	for i := range requests {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
+
+func (b *Batch) fetch(r BatchRequest) *BatchResult {
+	result := &BatchResult{Request: r}
+	if r.Name == "" {
+		result.Err = NameNotDeclaredErr
+		return result
+	}
+
+	bs := b.client.bootstrap
+	path := fmt.Sprintf(configPathFmt, defaultVal(r.Label, bs.Label), r.Name, defaultVal(r.Profile, b.client.resolveProfile()), extPROP)
+	content, err := b.client.send(http.MethodGet, path, "", "")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Properties = parseProperties(content)
+	return result
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchFetch(t *testing.T) {
+	var active, peak int32
+	full := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		// hold requests until every worker is busy
+		if n == 3 {
+			once.Do(func() { close(full) })
+		}
+		<-release
+
+		if r.URL.Path == "/master/missing-default.properties" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("path:  " + r.URL.Path + "\n"))
+	}))
+	defer server.Close()
+
+	batch, err := NewBatch(Bootstrap{URI: server.URL})
+	assert.NoError(t, err)
+	batch.Workers = 3
+
+	requests := []BatchRequest{
+		{Name: "a"}, {Name: "b", Profile: "prod"}, {Name: "c", Label: "v1"},
+		{Name: "missing"}, {Name: "d"}, {Name: "e"}, {},
+	}
+	done := make(chan []*BatchResult)
+	go func() {
+		done <- batch.Fetch(requests)
+	}()
+
+	select {
+	case <-full:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected 3 concurrent fetches")
+	}
+	close(release)
+	results := <-done
+
+	assert.Len(t, results, len(requests))
+	assert.Equal(t, "/master/a-default.properties", results[0].Properties["path"])
+	assert.Equal(t, "/master/b-prod.properties", results[1].Properties["path"])
+	assert.Equal(t, "/v1/c-default.properties", results[2].Properties["path"])
+	assert.Error(t, results[3].Err)
+	assert.NoError(t, results[4].Err)
+	assert.Equal(t, NameNotDeclaredErr, results[6].Err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&peak))
+}
+
+func TestBatchFetchResolvesProfile(t *testing.T) {
+	t.Setenv(EnvConfigProfile, "prod")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("path:  " + r.URL.Path + "\n"))
+	}))
+	defer server.Close()
+
+	batch, err := NewBatch(Bootstrap{URI: server.URL})
+	assert.NoError(t, err)
+	results := batch.Fetch([]BatchRequest{{Name: "a"}, {Name: "b", Profile: "qa"}})
+	assert.Equal(t, "/master/a-prod.properties", results[0].Properties["path"])
+	assert.Equal(t, "/master/b-qa.properties", results[1].Properties["path"])
+}
+
+func TestBatchResultJSON(t *testing.T) {
+	b, err := json.Marshal([]*BatchResult{
+		{Request: BatchRequest{Name: "a"}, Properties: Properties{"foo": "bar"}},
+		{Request: BatchRequest{Name: "b"}, Err: errors.New("config server returned 404")},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"request": {"name": "a"}, "properties": {"foo": "bar"}},
+		{"request": {"name": "b"}, "error": "config server returned 404"}
+	]`, string(b))
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	anyType         = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// bindProperties populates target, a pointer, from the flattened properties p as
+// Fetch would from the JSON document.  Values are converted to the numbers and
+// booleans expected by the fields they bind to
+func bindProperties(p Properties, target interface{}) error {
+	tree, err := Unflatten(p)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(coerce(tree, reflect.TypeOf(target)))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, target)
+}
+
+// coerce converts the string values of a tree built by Unflatten into the JSON
+// types expected by t.  Values bound to types which unmarshal themselves are kept
+// as strings
+func coerce(node interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+		return node
+	}
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = coerce(child, fieldType(t, k))
+		}
+	case []interface{}:
+		elem := anyType
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			elem = t.Elem()
+		}
+		for i, child := range v {
+			v[i] = coerce(child, elem)
+		}
+	case string:
+		switch t.Kind() {
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if _, err := strconv.ParseFloat(v, 64); err == nil {
+				return json.Number(v)
+			}
+		}
+	}
+	return node
+}
+
+// fieldType returns the type key binds to within t, following the encoding/json
+// rules for field names, or the empty interface if it is unknown
+func fieldType(t reflect.Type, key string) reflect.Type {
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" || f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+			if f.Anonymous && name == "" {
+				ft := f.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					if embedded := fieldType(ft, key); embedded != anyType {
+						return embedded
+					}
+					continue
+				}
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if strings.EqualFold(name, key) {
+				return f.Type
+			}
+		}
+	}
+	return anyType
+}
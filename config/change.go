@@ -0,0 +1,118 @@
+package config
+
+import (
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+const (
+	wildcardSegment = "*"
+	wildcardAny     = "**"
+)
+
+// ChangeFunc is notified with the previous and new value of a changed property.  A
+// property which was added has an empty old value and one which was removed has an
+// empty new value
+type ChangeFunc func(old, new string)
+
+type changeListener struct {
+	pattern []string
+	fn      ChangeFunc
+}
+
+func (c *client) OnChange(pattern string, fn ChangeFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changeListeners = append(c.changeListeners, &changeListener{pattern: keySegments(pattern), fn: fn})
+}
+
+// notifyChanges calls the change listeners whose pattern matches a property which
+// differs between old and new.  Keys are visited in sorted order and the listeners
+// for a key in registration order
+func (c *client) notifyChanges(old, new Properties, listeners []*changeListener) {
+	if old == nil || len(listeners) == 0 {
+		return
+	}
+
+	for _, k := range changedKeys(old, new) {
+		for _, l := range listeners {
+			if l.matches(k) {
+				l.call(k, old[k], new[k])
+			}
+		}
+	}
+}
+
+// changedKeys returns the sorted keys which were added, removed or modified
+// between old and new
+func changedKeys(old, new Properties) []string {
+	keys := Properties{}
+	for k, v := range new {
+		if ov, ok := old[k]; !ok || ov != v {
+			keys[k] = v
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			keys[k] = ""
+		}
+	}
+	return keys.Keys()
+}
+
+// matches returns true if key matches the listener pattern.  A "*" segment matches
+// exactly one key segment, including a list index, and a trailing "**" matches any
+// remaining segments (ex. datasource.** matches datasource.mysql.user and
+// servers.** matches servers[0].host).  A pattern naming a list matches its elements
+func (l *changeListener) matches(key string) bool {
+	return matchPattern(l.pattern, key)
+}
+
+func matchPattern(pattern []string, key string) bool {
+	segments := keySegments(key)
+	for i, p := range pattern {
+		if p == wildcardAny && i == len(pattern)-1 {
+			return len(segments) > i
+		}
+		if i >= len(segments) || (p != wildcardSegment && p != segments[i]) {
+			return false
+		}
+	}
+	for _, s := range segments[len(pattern):] {
+		if !strings.HasPrefix(s, "[") {
+			return false
+		}
+	}
+	return true
+}
+
+// keySegments splits a property key or pattern into its segments.  List indexes
+// are segments of their own (servers[0].host is servers, [0] and host).  A key
+// which cannot be parsed is split on dots
+func keySegments(key string) []string {
+	parsed, err := parseKey(key)
+	if err != nil {
+		return strings.Split(key, ".")
+	}
+	segments := make([]string, len(parsed))
+	for i, s := range parsed {
+		if s.list {
+			segments[i] = "[" + strconv.Itoa(s.index) + "]"
+		} else {
+			segments[i] = s.key
+		}
+	}
+	return segments
+}
+
+// call invokes the listener recovering and logging any panic so the remaining
+// listeners are still notified
+func (l *changeListener) call(key, old, new string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Change listener for %s panicked: %v\n%s", key, r, debug.Stack())
+		}
+	}()
+	l.fn(old, new)
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChangeListenerMatches(t *testing.T) {
+	l := &changeListener{pattern: []string{"datasource", "**"}}
+	assert.True(t, l.matches("datasource.url"))
+	assert.True(t, l.matches("datasource.mysql.user"))
+	assert.False(t, l.matches("datasource"))
+	assert.False(t, l.matches("datasources.url"))
+
+	l = &changeListener{pattern: []string{"datasource", "*", "user"}}
+	assert.True(t, l.matches("datasource.mysql.user"))
+	assert.False(t, l.matches("datasource.mysql.pass"))
+	assert.False(t, l.matches("datasource.mysql.user.name"))
+
+	l = &changeListener{pattern: keySegments("servers.**")}
+	assert.True(t, l.matches("servers[0].host"))
+	assert.True(t, l.matches("servers[1]"))
+	assert.False(t, l.matches("servers"))
+
+	l = &changeListener{pattern: keySegments("datasource.hosts")}
+	assert.True(t, l.matches("datasource.hosts"))
+	assert.True(t, l.matches("datasource.hosts[0]"))
+	assert.True(t, l.matches("datasource.hosts[1][0]"))
+	assert.False(t, l.matches("datasource.hosts[0].name"))
+	assert.False(t, l.matches("datasource.hostsx[0]"))
+
+	l = &changeListener{pattern: keySegments("servers.*.host")}
+	assert.True(t, l.matches("servers[0].host"))
+	assert.False(t, l.matches("servers[0].port"))
+
+	l = &changeListener{pattern: keySegments("servers[1].host")}
+	assert.True(t, l.matches("servers[1].host"))
+	assert.False(t, l.matches("servers[0].host"))
+
+	// bracketed map keys are not list indexes
+	l = &changeListener{pattern: keySegments("routes")}
+	assert.False(t, l.matches("routes[/api/v1.0]"))
+}
+
+func TestOnChangeIndexedKeys(t *testing.T) {
+	server := startChangingServer(
+		"datasource.hosts[0]:  a\ndatasource.hosts[1]:  b\nservers[0].host:  x\n",
+		"datasource.hosts[0]:  a\ndatasource.hosts[1]:  c\nservers[0].host:  y\n",
+	)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	changes := []string{}
+	cfg.OnChange("datasource.hosts", func(old, new string) {
+		changes = append(changes, old+"->"+new)
+	})
+	cfg.OnChange("servers.**", func(old, new string) {
+		changes = append(changes, old+"->"+new)
+	})
+
+	cfg.Refresh()
+	cfg.Refresh()
+	assert.Equal(t, []string{"b->c", "x->y"}, changes)
+}
+
+func TestOnChange(t *testing.T) {
+	server := startChangingServer(
+		"datasource.url:  db1\ndatasource.user:  app\nfoo:  bar\n",
+		"datasource.url:  db2\nfoo:  baz\n",
+	)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+
+	changes := []string{}
+	cfg.OnChange("datasource.**", func(old, new string) {
+		changes = append(changes, old+"->"+new)
+	})
+	cfg.OnChange("datasource.url", func(old, new string) {
+		panic("boom")
+	})
+	cfg.OnChange("other", func(old, new string) {
+		changes = append(changes, "other")
+	})
+
+	cfg.Refresh()
+	assert.Empty(t, changes)
+
+	cfg.Refresh()
+	assert.Equal(t, []string{"db1->db2", "app->"}, changes)
+}
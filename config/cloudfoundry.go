@@ -0,0 +1,52 @@
+package config
+
+import (
+	"github.com/ContainX/go-springcloud/cloudfoundry"
+)
+
+// PopulateFromCloudFoundry fills the unset URI, OAuth2 and Name settings from a
+// Spring Cloud Services config server bound in VCAP_SERVICES and the application
+// name in VCAP_APPLICATION.  New, NewBatch and LoadFromFile call it when the
+// CloudFoundry setting is enabled and it does nothing outside Cloud Foundry.  The
+// returned bool indicates a bound config server
+func (b *Bootstrap) PopulateFromCloudFoundry() (bool, error) {
+	app, err := cloudfoundry.ReadApplication()
+	if err != nil {
+		return false, err
+	}
+	if app != nil && b.Name == "" {
+		b.Name = app.ApplicationName
+	}
+
+	services, err := cloudfoundry.ReadServices()
+	if err != nil {
+		return false, err
+	}
+	svc := services.ConfigServer()
+	if svc == nil {
+		return false, nil
+	}
+
+	if b.URI == "" {
+		b.URI = svc.Credential(cloudfoundry.CredentialURI)
+	}
+	if b.OAuth2 == nil && svc.Credential(cloudfoundry.CredentialClientId) != "" {
+		b.OAuth2 = &OAuth2{
+			ClientId:       svc.Credential(cloudfoundry.CredentialClientId),
+			ClientSecret:   svc.Credential(cloudfoundry.CredentialClientSecret),
+			AccessTokenUri: svc.Credential(cloudfoundry.CredentialAccessTokenURI),
+		}
+	}
+	log.Infof("Using config server %s bound to %s", redactURI(b.URI), svc.Name)
+	return true, nil
+}
+
+// populateFromCloudFoundry calls PopulateFromCloudFoundry when b opts in with the
+// CloudFoundry setting
+func (b *Bootstrap) populateFromCloudFoundry() error {
+	if !b.CloudFoundry {
+		return nil
+	}
+	_, err := b.PopulateFromCloudFoundry()
+	return err
+}
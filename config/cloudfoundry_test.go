@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"github.com/ContainX/go-springcloud/cloudfoundry"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCloudFoundryBinding(t *testing.T) {
+	var tokenRequests int32
+	uaa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenRequests, 1)
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "config-client" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"access_token": "abc", "token_type": "bearer", "expires_in": 3600}`))
+	}))
+	defer uaa.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("foo:  bar\n"))
+	}))
+	defer server.Close()
+
+	t.Setenv(cloudfoundry.EnvApplication, `{"application_name": "myapp"}`)
+	t.Setenv(cloudfoundry.EnvServices, fmt.Sprintf(`{"p-config-server": [{"name": "config-server", "credentials": {
+		"uri": %q, "client_id": "config-client", "client_secret": "s3cret", "access_token_uri": %q}}]}`, server.URL, uaa.URL))
+
+	_, err := New(Bootstrap{})
+	assert.Equal(t, NameNotDeclaredErr, err)
+
+	cfg, err := New(Bootstrap{CloudFoundry: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "myapp", cfg.Bootstrap().Name)
+	assert.Equal(t, server.URL, cfg.Bootstrap().URI)
+	assert.Equal(t, "config-client", cfg.Bootstrap().OAuth2.ClientId)
+
+	for i := 0; i < 2; i++ {
+		m, err := cfg.FetchAsMap()
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", m["foo"])
+	}
+	// the token is cached until it expires
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+}
+
+func TestCloudFoundryBindingKeepsDeclaredSettings(t *testing.T) {
+	t.Setenv(cloudfoundry.EnvApplication, `{"application_name": "cf-name"}`)
+	t.Setenv(cloudfoundry.EnvServices, `{"p.config-server": [{"credentials": {"uri": "https://config", "client_id": "id"}}]}`)
+
+	b := Bootstrap{Name: "myapp", URI: "http://localhost:9999"}
+	bound, err := b.PopulateFromCloudFoundry()
+	assert.NoError(t, err)
+	assert.True(t, bound)
+	assert.Equal(t, "myapp", b.Name)
+	assert.Equal(t, "http://localhost:9999", b.URI)
+	assert.Equal(t, "id", b.OAuth2.ClientId)
+
+	t.Setenv(cloudfoundry.EnvServices, "")
+	bound, err = (&Bootstrap{}).PopulateFromCloudFoundry()
+	assert.NoError(t, err)
+	assert.False(t, bound)
+}
@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ContainX/go-utils/encoding"
-	"github.com/ContainX/go-utils/envsubst"
-	"github.com/ContainX/go-utils/httpclient"
+	"github.com/ContainX/go-utils/logger"
+	"github.com/xeipuuv/gojsonschema"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -24,8 +27,8 @@ const (
 	ProfileDefault = "default"
 	// LabelDefault is the initial SCM branch
 	LabelDefault = "master"
-	// Format is {uri}/{label}/{name}-{profile}.type
-	configPathFmt = "%s/%s/%s-%s.%s"
+	// Format is {label}/{name}-{profile}.type
+	configPathFmt = "%s/%s-%s.%s"
 	extJSON       = "json"
 	extPROP       = "properties"
 	extYAML       = "yml"
@@ -36,13 +39,19 @@ var (
 	FileNotDeclaredErr = errors.New("Filename must have a value")
 )
 
+// LoggerName is the name of the logger used by the package
+const LoggerName = "config"
+
+var log = logger.GetLogger(LoggerName)
+
 type ConfigClient interface {
 	// Fetch queries the remote configuration service and populates the
-	// target value
+	// target value.  If a schema is declared the configuration is validated first
+	// and a ValidationError returned when it does not conform
 	Fetch(target interface{}) error
 
-	// FetchWithSubstitution fetches a remote config, substitutes environment variables
-	// and writes it to the target
+	// FetchWithSubstitution fetches a remote config, substitutes variables (environment
+	// variables by default, see Bootstrap.Substitution) and writes it to the target
 	FetchWithSubstitution(target interface{}) error
 
 	// Fetch queries the remote configuration service and populates
@@ -50,6 +59,18 @@ type ConfigClient interface {
 	// into flattened form.  Example:  datasource.mysql.user
 	FetchAsMap() (map[string]string, error)
 
+	// FetchAsTree fetches the flattened properties and reconstructs the nested maps
+	// and slices they represent (see Unflatten)
+	FetchAsTree() (map[string]interface{}, error)
+
+	// FetchAsRedactedMap is FetchAsMap with the values of sensitive keys masked so the
+	// result can be printed or logged.  See DefaultSanitizePatterns and SanitizePatterns
+	FetchAsRedactedMap() (Properties, error)
+
+	// FetchAsEnv fetches the flattened properties and renders them as environment
+	// variables named following Spring relaxed binding (DATASOURCE_MYSQL_USER)
+	FetchAsEnv(format EnvFormat) (string, error)
+
 	// Fetch queries the remote configuration service and returns
 	// the result as a JSON string
 	FetchAsJSON() (string, error)
@@ -62,12 +83,88 @@ type ConfigClient interface {
 	// the result as a Properties string
 	FetchAsProperties() (string, error)
 
+	// Encrypt sends plain to the server /encrypt endpoint and returns the cipher text.
+	// A {key:alias} prefix (see WithKeyAlias) selects a specific server key
+	Encrypt(plain string) (string, error)
+
+	// Decrypt sends cipher to the server /decrypt endpoint and returns the plain text.
+	// A leading {cipher} marker is stripped, {key:alias} prefixes are passed through
+	Decrypt(cipher string) (string, error)
+
+	// EncryptStatus queries the server /encrypt/status endpoint.  If the server has
+	// no key installed the status is returned along with EncryptionNotAvailableErr
+	EncryptStatus() (*EncryptStatus, error)
+
+	// Refresh fetches the configuration and notifies the OnRefresh listeners if the
+	// properties changed since the previous refresh.  Returns true on a change.
+	// Configuration failing schema validation is not applied
+	Refresh() (bool, error)
+
+	// OnRefresh registers fn to be called after each refresh which changed the properties.
+	// A panic in fn is logged and does not prevent the remaining listeners being called
+	OnRefresh(fn RefreshFunc)
+
+	// OnChange registers fn to be called when a property matching pattern changes
+	// during a refresh.  The pattern is a key (datasource.url), may use "*" to match
+	// a single segment or end with "**" to match every key below a prefix
+	// (datasource.**).  List indexes are segments of their own and a pattern naming
+	// a list (datasource.hosts) matches its elements (datasource.hosts[0]).  Listeners
+	// run serially and panics are recovered and logged
+	OnChange(pattern string, fn ChangeFunc)
+
+	// Properties returns a copy of the properties applied by the last successful refresh
+	// (or the pinned snapshot) or nil if the client has not been refreshed
+	Properties() Properties
+
+	// Watch calls Refresh immediately and then every interval until stop is closed.
+	// Errors are logged and the previous properties remain in effect
+	Watch(interval time.Duration, stop <-chan struct{})
+
+	// FetchEnvironment queries the environment endpoint ({name}/{profile}/{label})
+	// returning the raw property sources and repository version
+	FetchEnvironment() (*Environment, error)
+
+	// FetchWithOrigins resolves every property along with the property source it came
+	// from and the values it shadows, layering local sources beneath the remote ones
+	// and the config trees above them
+	FetchWithOrigins(local LocalSources) (map[string]*OriginProperty, error)
+
+	// History returns the snapshots recorded by Refresh, oldest first
+	History() []*Snapshot
+
+	// Diff returns the changes between the snapshots with versions a and b
+	Diff(a, b string) ([]PropertyChange, error)
+
+	// Pin applies the snapshot with version and holds the client on it, ignoring
+	// refreshed configuration until Unpin is called.  The pin is persisted along with
+	// the history when History.Dir is set.  It only affects the applied configuration
+	// (Properties and the refresh listeners); the Fetch methods always query the server
+	Pin(version string) error
+
+	// Unpin releases a pin and applies the most recently fetched configuration
+	Unpin()
+
+	// Pinned returns the pinned version or "" if the client is not pinned
+	Pinned() string
+
 	// Bootstrap returns a reference to the current bootstrap settings
 	Bootstrap() *Bootstrap
 }
 
 type client struct {
 	bootstrap *Bootstrap
+	token     *tokenSource
+	sanitizer *Sanitizer
+	schema    *gojsonschema.Schema
+
+	// refreshMu serializes refreshes while mu guards the refresh state
+	refreshMu       sync.Mutex
+	mu              sync.RWMutex
+	current         Properties
+	listeners       []RefreshFunc
+	changeListeners []*changeListener
+	history         []*Snapshot
+	pinned          *Snapshot
 }
 
 // Bootstrap is the properties needed to fetch a remote configuration from
@@ -85,9 +182,14 @@ type Bootstrap struct {
 	// Default is "default".
 	//
 	// Note: During runtime the config client looks for the presence of an environment
-	// variable called CONFIG_PROFILE.  If this is defined it overwrites this value.
+	// variable called CONFIG_PROFILE.  If this is defined it overwrites this value
+	// unless ExplicitProfile is set.
 	Profile string `json:"profile"`
 
+	// ExplicitProfile ignores CONFIG_PROFILE, for clients fetching a profile chosen
+	// by the caller such as the targets of a diff.
+	ExplicitProfile bool `json:"-"`
+
 	// Name of application used to fetch remote properties.
 	Name string `json:"name"`
 
@@ -100,26 +202,89 @@ type Bootstrap struct {
 
 	// The password to use (HTTP Basic) when contacting the remote server.
 	Password string `json:"password,omitempty"`
+
+	// OAuth2 client credentials used instead of Username and Password.  They are
+	// populated from VCAP_SERVICES on Cloud Foundry (see PopulateFromCloudFoundry).
+	OAuth2 *OAuth2 `json:"oauth2,omitempty"`
+
+	// Discovery locates the remote server through Eureka instead of URI when enabled.
+	Discovery Discovery `json:"discovery"`
+
+	// Substitution controls the resolution of variables within fetched configuration.
+	Substitution Substitution `json:"substitution"`
+
+	// SchemaFile is the path to a JSON Schema the fetched configuration must conform
+	// to before it is applied by Fetch or Refresh.
+	SchemaFile string `json:"schemaFile,omitempty"`
+
+	// Schema is an embedded JSON Schema document.  It takes precedence over SchemaFile.
+	Schema []byte `json:"-"`
+
+	// History controls the snapshots of fetched configuration kept for rollback.
+	History History `json:"history"`
+
+	// SanitizePatterns are additional key patterns, along with DefaultSanitizePatterns,
+	// whose values are masked when properties are printed or logged.
+	SanitizePatterns []string `json:"sanitizePatterns,omitempty"`
+
+	// DisableLogLevels stops logging.level.* properties from being applied to the
+	// loggers on each refresh.  Levels are process-wide so only one client should
+	// apply them.
+	DisableLogLevels bool `json:"disableLogLevels,omitempty"`
+
+	// ConfigTree are directories, such as /run/secrets, read as properties with a
+	// property per file (see ReadConfigTree).  They take precedence over the remote
+	// configuration and later directories over earlier ones.  Entries may use the
+	// Spring "optional:configtree:" prefixes.
+	ConfigTree []string `json:"configTree,omitempty"`
+
+	// CloudFoundry populates the unset URI, OAuth2 and Name settings from the
+	// VCAP_SERVICES and VCAP_APPLICATION environment when the client is created
+	// (see PopulateFromCloudFoundry).
+	CloudFoundry bool `json:"cloudFoundry,omitempty"`
 }
 
 // New creates a new ConfigClient based on b Bootstrap
 // Error will be thrown if Name is not set
 func New(b Bootstrap) (ConfigClient, error) {
+	if err := b.populateFromCloudFoundry(); err != nil {
+		return nil, err
+	}
 	if b.Name == "" {
 		return nil, NameNotDeclaredErr
 	}
+	return newClient(b)
+}
 
+// newClient creates a client from b, applying the defaults of unset settings
+func newClient(b Bootstrap) (*client, error) {
 	b.URI = defaultVal(b.URI, UriDefault)
 	b.Profile = defaultVal(b.Profile, ProfileDefault)
 	b.Label = defaultVal(b.Label, LabelDefault)
+	b.Discovery.ServiceId = defaultVal(b.Discovery.ServiceId, ServiceIdDefault)
 
 	client := &client{
 		bootstrap: &b,
 	}
+	if err := client.setup(); err != nil {
+		return nil, err
+	}
 	return client, nil
 }
 
+// LoadFromFile creates a new ConfigClient from the bootstrap settings in filename
+// (see ReadBootstrap)
 func LoadFromFile(filename string) (ConfigClient, error) {
+	b, err := ReadBootstrap(filename)
+	if err != nil {
+		return nil, err
+	}
+	return New(*b)
+}
+
+// ReadBootstrap reads the bootstrap settings from a json or yml file without
+// creating a client, so they can be amended before calling New
+func ReadBootstrap(filename string) (*Bootstrap, error) {
 	if filename == "" {
 		return nil, FileNotDeclaredErr
 	}
@@ -128,24 +293,17 @@ func LoadFromFile(filename string) (ConfigClient, error) {
 	if err != nil {
 		return nil, err
 	}
-
 	defer f.Close()
-	if encoder, err := encoding.NewEncoderFromFileExt(filename); err == nil {
-		config := &Bootstrap{}
-		if e := encoder.UnMarshal(f, config); e != nil {
-			return nil, e
-		}
-		config.PopulateDefaultsIfEmpty()
-		if config.Name == "" {
-			return nil, NameNotDeclaredErr
-		}
-		client := &client{
-			bootstrap: config,
-		}
-		return client, nil
-	} else {
+
+	encoder, err := encoding.NewEncoderFromFileExt(filename)
+	if err != nil {
 		return nil, err
 	}
+	b := &Bootstrap{}
+	if err := encoder.UnMarshal(f, b); err != nil {
+		return nil, err
+	}
+	return b, nil
 }
 
 func (b *Bootstrap) PopulateDefaultsIfEmpty() {
@@ -158,6 +316,24 @@ func (b *Bootstrap) PopulateDefaultsIfEmpty() {
 	if b.Label == "" {
 		b.Label = LabelDefault
 	}
+	if b.Discovery.ServiceId == "" {
+		b.Discovery.ServiceId = ServiceIdDefault
+	}
+}
+
+// setup prepares the collaborators derived from the bootstrap settings
+func (c *client) setup() (err error) {
+	if c.sanitizer, err = NewSanitizer(c.bootstrap.SanitizePatterns...); err != nil {
+		return err
+	}
+	c.token = newTokenSource(c.bootstrap.OAuth2)
+	if err = c.loadSchema(); err != nil {
+		return err
+	}
+	if err = c.loadHistory(); err != nil {
+		return err
+	}
+	return c.initDiscovery()
 }
 
 // defaultVal returns "d" if "s" aka source has an empty value
@@ -172,6 +348,9 @@ func defaultVal(s, d string) string {
 // either use the CLUSTER_PROFILE env variable or fallback to
 // the specified default value as a fallback
 func (c *client) resolveProfile() string {
+	if c.bootstrap.ExplicitProfile {
+		return c.bootstrap.Profile
+	}
 	if v := os.Getenv(EnvConfigProfile); v != "" {
 		return v
 	}
@@ -191,12 +370,19 @@ func (c *client) resolveURI() string {
 // Fetch queries the remote configuration service and populates the
 // target value
 func (c *client) Fetch(target interface{}) error {
-	uri := c.buildRequestURI(extJSON)
-	resp := httpclient.Get(uri, target)
-	if resp.Error != nil {
-		return resp.Error
+	content, err := c.fetch(extJSON)
+	if err != nil {
+		return err
 	}
-	return nil
+	if content, err = c.overlayContent(extJSON, content); err != nil {
+		return err
+	}
+	if err = c.validate(content); err != nil {
+		return err
+	}
+
+	enc, _ := encoding.NewEncoder(encoding.JSON)
+	return enc.UnMarshalStr(content, target)
 }
 
 func (c *client) FetchWithSubstitution(target interface{}) error {
@@ -214,20 +400,23 @@ func (c *client) FetchWithSubstitution(target interface{}) error {
 }
 
 func (c *client) FetchAsMap() (map[string]string, error) {
-	uri := c.buildRequestURI(extPROP)
-	resp := httpclient.Get(uri, nil)
-	if resp.Error != nil {
-		return nil, resp.Error
+	content, err := c.fetch(extPROP)
+	if err != nil {
+		return nil, err
 	}
+	return c.overlayMap(parseProperties(content))
+}
 
+// parseProperties parses the "key: value" lines returned by the properties endpoint
+func parseProperties(content string) map[string]string {
 	m := map[string]string{}
-	for _, line := range strings.Split(resp.Content, "\n") {
-		kv := strings.Split(line, ":")
+	for _, line := range strings.Split(content, "\n") {
+		kv := strings.SplitN(line, ":", 2)
 		if len(kv) == 2 {
 			m[kv[0]] = strings.TrimSpace(kv[1])
 		}
 	}
-	return m, nil
+	return m
 }
 
 func (c *client) FetchAsProperties() (string, error) {
@@ -243,24 +432,28 @@ func (c *client) FetchAsYAML() (string, error) {
 }
 
 func (c *client) fetchAsString(extension string) (string, error) {
-	uri := c.buildRequestURI(extension)
-
-	resp := httpclient.Get(uri, nil)
-	content := resp.Content
-	if resp.Error == nil {
-		content = envsubst.Substitute(strings.NewReader(content), false, func(s string) string {
-			return os.Getenv(s)
-		})
+	content, err := c.fetch(extension)
+	if err != nil {
+		return "", err
+	}
+	// config tree values are layered after substitution so secrets are kept verbatim
+	if content, err = c.substitute(content); err != nil {
+		return "", err
 	}
-	return content, resp.Error
+	return c.overlayContent(extension, content)
+}
+
+// fetch retrieves the remote configuration in the format of extension
+func (c *client) fetch(extension string) (string, error) {
+	return c.send(http.MethodGet, c.buildRequestPath(extension), "", "")
 }
 
 func (c *client) Bootstrap() *Bootstrap {
 	return c.bootstrap
 }
 
-// Builds the final request URI for fetching a remote configuration.
-// The returned URI is in the format of : {uri}/{label}/{name}-{profile}.json
-func (c *client) buildRequestURI(t string) string {
-	return fmt.Sprintf(configPathFmt, c.resolveURI(), c.bootstrap.Label, c.bootstrap.Name, c.resolveProfile(), t)
+// Builds the request path, relative to the server URI, for fetching a remote configuration.
+// The returned path is in the format of : {label}/{name}-{profile}.json
+func (c *client) buildRequestPath(t string) string {
+	return fmt.Sprintf(configPathFmt, c.bootstrap.Label, c.bootstrap.Name, c.resolveProfile(), t)
 }
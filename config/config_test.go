@@ -3,6 +3,8 @@ package config
 import (
 	"github.com/ContainX/go-utils/mockrest"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -16,6 +18,13 @@ type testStruct struct {
 	Foo string `json:"foo"`
 }
 
+// startServer starts a config server which responds to every request with body
+func startServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+}
+
 func TestConfigAsMap(t *testing.T) {
 	server := mockrest.StartNewWithFile(FilePropertyResp)
 	defer server.Stop()
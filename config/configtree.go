@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"github.com/ContainX/go-utils/encoding"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ConfigTreePrefix optionally prefixes Bootstrap.ConfigTree entries as in
+	// Spring's spring.config.import=configtree:/run/secrets/
+	ConfigTreePrefix = "configtree:"
+
+	// OptionalPrefix marks a config tree which is skipped when it does not exist
+	OptionalPrefix = "optional:"
+)
+
+// ReadConfigTree reads the file tree rooted at dir as properties.  Each file is a
+// property whose key is its path relative to dir with directories joined by "."
+// (db/password becomes db.password) and whose value is the file content without a
+// trailing newline.  Hidden files and directories, such as the ..data links
+// Kubernetes creates in mounted volumes, are skipped while symlinks are followed.
+// A symlink to a directory being read is skipped to avoid looping
+func ReadConfigTree(dir string) (Properties, error) {
+	p := Properties{}
+	if err := readConfigTree(p, dir, "", map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// readConfigTree reads dir into p.  ancestors holds the real paths of the
+// directories being read
+func readConfigTree(p Properties, dir, prefix string, ancestors map[string]bool) error {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if ancestors[real] {
+		log.Warningf("Skipping %s, a link to the config tree directory %s", dir, real)
+		return nil
+	}
+	ancestors[real] = true
+	defer delete(ancestors, real)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		key := prefix + e.Name()
+		if info.IsDir() {
+			if err := readConfigTree(p, path, key+".", ancestors); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		value := strings.TrimSuffix(string(b), "\n")
+		p[key] = strings.TrimSuffix(value, "\r")
+	}
+	return nil
+}
+
+// configTreeSources reads Bootstrap.ConfigTree as property sources in order of
+// precedence, the last declared tree first.  Trees are read on every fetch so
+// rotated secrets are picked up by a refresh
+func (c *client) configTreeSources() ([]*PropertySource, error) {
+	sources := []*PropertySource{}
+	for i := len(c.bootstrap.ConfigTree) - 1; i >= 0; i-- {
+		dir := strings.TrimPrefix(c.bootstrap.ConfigTree[i], OptionalPrefix)
+		optional := dir != c.bootstrap.ConfigTree[i]
+		dir = strings.TrimPrefix(dir, ConfigTreePrefix)
+
+		p, err := ReadConfigTree(dir)
+		if err != nil {
+			if optional && os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Error reading config tree %s: %s", dir, err.Error())
+		}
+
+		ps := &PropertySource{Name: ConfigTreePrefix + dir, Source: map[string]interface{}{}}
+		for k, v := range p {
+			ps.Source[k] = v
+		}
+		sources = append(sources, ps)
+	}
+	return sources, nil
+}
+
+// configTreeProperties merges the config trees, later trees overriding earlier ones
+func (c *client) configTreeProperties() (Properties, error) {
+	sources, err := c.configTreeSources()
+	if err != nil {
+		return nil, err
+	}
+
+	p := Properties{}
+	for i := len(sources) - 1; i >= 0; i-- {
+		for k, v := range sources[i].Source {
+			p[k] = v.(string)
+		}
+	}
+	return p, nil
+}
+
+// overlayMap layers the config trees above the remote properties m
+func (c *client) overlayMap(m map[string]string) (map[string]string, error) {
+	if len(c.bootstrap.ConfigTree) == 0 {
+		return m, nil
+	}
+
+	overlay, err := c.configTreeProperties()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range overlay {
+		m[k] = v
+	}
+	return m, nil
+}
+
+// overlayContent layers the config trees above a remote document in the format of
+// extension
+func (c *client) overlayContent(extension, content string) (string, error) {
+	if len(c.bootstrap.ConfigTree) == 0 {
+		return content, nil
+	}
+
+	overlay, err := c.configTreeProperties()
+	if err != nil {
+		return "", err
+	}
+	if len(overlay) == 0 {
+		return content, nil
+	}
+
+	if extension == extPROP {
+		m := parseProperties(content)
+		for k, v := range overlay {
+			m[k] = v
+		}
+		b := &strings.Builder{}
+		for _, k := range Properties(m).Keys() {
+			fmt.Fprintf(b, "%s: %s\n", k, strings.Replace(m[k], "\n", `\n`, -1))
+		}
+		return b.String(), nil
+	}
+
+	encType := encoding.JSON
+	if extension == extYAML {
+		encType = encoding.YAML
+	}
+	enc, _ := encoding.NewEncoder(encType)
+
+	var doc interface{} = map[string]interface{}{}
+	if err := enc.UnMarshalStr(content, &doc); err != nil {
+		return "", err
+	}
+	for _, k := range overlay.Keys() {
+		segments, err := parseKey(k)
+		if err != nil {
+			return "", err
+		}
+		doc = setPath(doc, segments, overlay[k])
+	}
+	return enc.Marshal(doc)
+}
+
+// setPath sets the value at segments within a decoded document, creating the
+// maps and list elements along the way.  The updated node is returned
+func setPath(node interface{}, segments []segment, value string) interface{} {
+	if len(segments) == 0 {
+		return overlayValue(node, value)
+	}
+
+	s := segments[0]
+	if s.list {
+		list, _ := node.([]interface{})
+		for len(list) <= s.index {
+			list = append(list, nil)
+		}
+		list[s.index] = setPath(list[s.index], segments[1:], value)
+		return list
+	}
+
+	switch m := node.(type) {
+	case map[string]interface{}:
+		m[s.key] = setPath(m[s.key], segments[1:], value)
+		return m
+	case map[interface{}]interface{}:
+		m[s.key] = setPath(m[s.key], segments[1:], value)
+		return m
+	}
+	return map[string]interface{}{s.key: setPath(nil, segments[1:], value)}
+}
+
+// overlayValue converts value to the type of the number or boolean it replaces
+// so it still binds to the same field
+func overlayValue(existing interface{}, value string) interface{} {
+	switch existing.(type) {
+	case bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case float64, int:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
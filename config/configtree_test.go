@@ -0,0 +1,117 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigTree creates a config tree laid out as Kubernetes mounts secrets, with
+// the files linked from a hidden ..data directory
+func writeConfigTree(t *testing.T) string {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "..data")
+	assert.NoError(t, os.MkdirAll(filepath.Join(data, "pool"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(data, "datasource.password"), []byte("s3cr$t\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(data, "pool", "size"), []byte("20"), 0600))
+	assert.NoError(t, os.Symlink(filepath.Join(data, "datasource.password"), filepath.Join(dir, "datasource.password")))
+	assert.NoError(t, os.Symlink(filepath.Join(data, "pool"), filepath.Join(dir, "pool")))
+	return dir
+}
+
+func startConfigTreeServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "."+extPROP):
+			w.Write([]byte("datasource.url: jdbc:mysql://db/app\ndatasource.password: changeme\npool.size: 10\n"))
+		case strings.HasSuffix(r.URL.Path, "."+extJSON):
+			w.Write([]byte(`{"datasource": {"url": "jdbc:mysql://db/app", "password": "changeme"}, "pool": {"size": 10}}`))
+		case strings.HasSuffix(r.URL.Path, "."+extYAML):
+			w.Write([]byte("datasource:\n  url: jdbc:mysql://db/app\n  password: changeme\npool:\n  size: 10\n"))
+		default:
+			w.Write([]byte(environmentJSON))
+		}
+	}))
+}
+
+func TestReadConfigTree(t *testing.T) {
+	p, err := ReadConfigTree(writeConfigTree(t))
+	assert.NoError(t, err)
+	assert.Equal(t, Properties{"datasource.password": "s3cr$t", "pool.size": "20"}, p)
+
+	_, err = ReadConfigTree(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestReadConfigTreeSymlinkLoop(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "db"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "db", "user"), []byte("app"), 0600)
+	assert.NoError(t, os.Symlink(dir, filepath.Join(dir, "db", "loop")))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "db"), filepath.Join(dir, "shared")))
+
+	p, err := ReadConfigTree(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, Properties{"db.user": "app", "shared.user": "app"}, p)
+}
+
+func TestConfigTreeOverlay(t *testing.T) {
+	server := startConfigTreeServer()
+	defer server.Close()
+
+	dir := writeConfigTree(t)
+	cfg, _ := New(Bootstrap{
+		Name:       "myapp",
+		URI:        server.URL,
+		ConfigTree: []string{"optional:configtree:/does/not/exist", ConfigTreePrefix + dir},
+	})
+
+	m, err := cfg.FetchAsMap()
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr$t", m["datasource.password"])
+	assert.Equal(t, "20", m["pool.size"])
+	assert.Equal(t, "jdbc:mysql://db/app", m["datasource.url"])
+
+	target := struct {
+		Datasource struct {
+			URL      string `json:"url"`
+			Password string `json:"password"`
+		} `json:"datasource"`
+		Pool struct {
+			Size int `json:"size"`
+		} `json:"pool"`
+	}{}
+	assert.NoError(t, cfg.Fetch(&target))
+	assert.Equal(t, "jdbc:mysql://db/app", target.Datasource.URL)
+	assert.Equal(t, "s3cr$t", target.Datasource.Password)
+	assert.Equal(t, 20, target.Pool.Size)
+
+	// secrets are layered after substitution and kept verbatim
+	content, err := cfg.FetchAsProperties()
+	assert.NoError(t, err)
+	assert.Contains(t, content, "datasource.password: s3cr$t\n")
+
+	content, err = cfg.FetchAsYAML()
+	assert.NoError(t, err)
+	assert.Contains(t, content, "password: s3cr$t")
+	assert.Contains(t, content, "size: 20")
+
+	props, err := cfg.FetchWithOrigins(LocalSources{Environ: []string{}})
+	assert.NoError(t, err)
+	assert.Equal(t, PropertyValue{Value: "20", Origin: ConfigTreePrefix + dir}, props["pool.size"].PropertyValue)
+	assert.Equal(t, "10", props["pool.size"].Shadowed[0].Value)
+}
+
+func TestConfigTreeMissing(t *testing.T) {
+	server := startConfigTreeServer()
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL, ConfigTree: []string{"/does/not/exist"}})
+	_, err := cfg.FetchAsMap()
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package config
+
+// DiffClients fetches the configuration of a and b, such as two profiles or labels of
+// an application, and returns the changes required to turn a into b
+func DiffClients(a, b ConfigClient) ([]PropertyChange, error) {
+	pa, err := a.FetchAsMap()
+	if err != nil {
+		return nil, err
+	}
+	pb, err := b.FetchAsMap()
+	if err != nil {
+		return nil, err
+	}
+	return DiffProperties(pa, pb), nil
+}
+
+// RedactChanges returns a copy of changes with the values of sensitive keys masked
+// by s.  Masked values which differ remain reported as changed
+func RedactChanges(changes []PropertyChange, s *Sanitizer) []PropertyChange {
+	redacted := make([]PropertyChange, len(changes))
+	for i, c := range changes {
+		c.Old = s.Sanitize(c.Key, c.Old)
+		c.New = s.Sanitize(c.Key, c.New)
+		redacted[i] = c
+	}
+	return redacted
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiffClients(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "-prod.properties") {
+			w.Write([]byte("foo:  baz\ndb.password:  prod\nadded:  1\n"))
+			return
+		}
+		w.Write([]byte("foo:  bar\ndb.password:  staging\nremoved:  1\n"))
+	}))
+	defer server.Close()
+
+	// explicit profiles are not replaced by CONFIG_PROFILE
+	t.Setenv(EnvConfigProfile, "staging")
+	staging, _ := New(Bootstrap{Name: "myapp", URI: server.URL, Profile: "staging", ExplicitProfile: true})
+	prod, _ := New(Bootstrap{Name: "myapp", URI: server.URL, Profile: "prod", ExplicitProfile: true})
+
+	changes, err := DiffClients(staging, prod)
+	assert.NoError(t, err)
+	assert.Equal(t, []PropertyChange{
+		{Key: "added", Type: Added, New: "1"},
+		{Key: "db.password", Type: Changed, Old: RedactedValue, New: RedactedValue},
+		{Key: "foo", Type: Changed, Old: "bar", New: "baz"},
+		{Key: "removed", Type: Removed, Old: "1"},
+	}, RedactChanges(changes, DefaultSanitizer))
+	assert.Equal(t, "prod", changes[1].New)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ContainX/go-springcloud/discovery/eureka"
+	"github.com/ContainX/go-springcloud/discovery/eureka/model"
+	"strings"
+)
+
+const (
+	// ServiceIdDefault is the Eureka application name of the config server
+	ServiceIdDefault = "CONFIGSERVER"
+
+	metaConfigPath = "configPath"
+	metaUser       = "user"
+	metaPassword   = "password"
+	userDefault    = "user"
+	instanceUrlFmt = "%s://%s:%d"
+)
+
+var (
+	DiscoveryNotDeclaredErr = errors.New("Discovery requires a Eureka client or service urls")
+	NoServerAvailableErr    = errors.New("No config server instances are available")
+)
+
+// Discovery holds the settings used to locate the configuration server through
+// Eureka (spring.cloud.config.discovery).
+type Discovery struct {
+	// Enabled looks up the config server in Eureka rather than using URI.
+	Enabled bool `json:"enabled"`
+
+	// ServiceId is the name the config server is registered with (default CONFIGSERVER).
+	ServiceId string `json:"serviceId"`
+
+	// ServiceUrls of the Eureka servers.  Used to create a client when Client is not set.
+	ServiceUrls []string `json:"serviceUrls,omitempty"`
+
+	// Client is the Eureka client used to look up config server instances.
+	Client eureka.EurekaClient `json:"-"`
+}
+
+// initDiscovery creates the Eureka client from the service urls if discovery is
+// enabled and no client has been provided
+func (c *client) initDiscovery() error {
+	d := &c.bootstrap.Discovery
+	if !d.Enabled || d.Client != nil {
+		return nil
+	}
+	if len(d.ServiceUrls) == 0 {
+		return DiscoveryNotDeclaredErr
+	}
+	d.Client = eureka.NewClient(&model.EurekaConfig{
+		Client: model.EurekaClientConfig{ServiceUrls: d.ServiceUrls},
+	})
+	return nil
+}
+
+// discoverEndpoints queries Eureka for UP instances of the config server.  The
+// configPath, user and password instance metadata are honoured the same way as
+// the Spring config client, although OAuth2 credentials take precedence over them
+func (c *client) discoverEndpoints() ([]*endpoint, error) {
+	d := c.bootstrap.Discovery
+	app, err := d.Client.GetApplication(d.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, NoServerAvailableErr
+	}
+
+	endpoints := []*endpoint{}
+	for _, i := range app.Instances {
+		if i.Status != model.UP {
+			continue
+		}
+		endpoints = append(endpoints, c.instanceEndpoint(i))
+	}
+
+	if len(endpoints) == 0 {
+		return nil, NoServerAvailableErr
+	}
+	return endpoints, nil
+}
+
+func (c *client) instanceEndpoint(i *model.Instance) *endpoint {
+	host := defaultVal(i.HostName, i.IpAddr)
+	uri := fmt.Sprintf(instanceUrlFmt, "http", host, i.Port.Number)
+	if i.SecurePort.Enabled {
+		uri = fmt.Sprintf(instanceUrlFmt, "https", host, i.SecurePort.Number)
+	}
+
+	ep := &endpoint{
+		uri:      uri,
+		username: c.bootstrap.Username,
+		password: c.bootstrap.Password,
+		token:    c.token,
+	}
+
+	if path, ok := i.Metadata[metaConfigPath]; ok && path != "" {
+		ep.uri = ep.uri + "/" + strings.Trim(path, "/")
+	}
+	if password, ok := i.Metadata[metaPassword]; ok {
+		ep.password = password
+		ep.username = defaultVal(i.Metadata[metaUser], userDefault)
+	}
+	return ep
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"github.com/ContainX/go-springcloud/discovery/eureka/model"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// fakeEureka returns a fixed application for every lookup
+type fakeEureka struct {
+	app *model.Application
+}
+
+func (f *fakeEureka) Register(await bool) error                            { return nil }
+func (f *fakeEureka) Unregister()                                          {}
+func (f *fakeEureka) GetInstance(name, id string) (*model.Instance, error) { return nil, nil }
+func (f *fakeEureka) GetCurrentInstance() (*model.Instance, error)         { return nil, nil }
+func (f *fakeEureka) GetApplications() (map[string]*model.Application, error) {
+	return map[string]*model.Application{f.app.Name: f.app}, nil
+}
+func (f *fakeEureka) GetApplication(name string) (*model.Application, error) {
+	if name != f.app.Name {
+		return nil, nil
+	}
+	return f.app, nil
+}
+
+func instanceFor(rawurl string, status model.StatusType, meta model.Metadata) *model.Instance {
+	u, _ := url.Parse(rawurl)
+	host, port, _ := net.SplitHostPort(u.Host)
+	p, _ := strconv.Atoi(port)
+	return &model.Instance{HostName: host, Port: model.Port{Number: p, Enabled: true}, Status: status, Metadata: meta}
+}
+
+func TestDiscoveryFailover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, _ := r.BasicAuth(); u != "cfg" || p != "pw" || r.URL.Path != "/config/master/myapp-default.properties" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("foo: bar\n"))
+	}))
+	defer server.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	eureka := &fakeEureka{app: &model.Application{
+		Name: ServiceIdDefault,
+		Instances: []*model.Instance{
+			instanceFor("http://127.0.0.1:1", model.DOWN, nil),
+			instanceFor(down.URL, model.UP, nil),
+			instanceFor(server.URL, model.UP, model.Metadata{"configPath": "/config", "user": "cfg", "password": "pw"}),
+		},
+	}}
+
+	cfg, err := New(Bootstrap{Name: "myapp", Discovery: Discovery{Enabled: true, Client: eureka}})
+	assert.NoError(t, err)
+
+	m, err := cfg.FetchAsMap()
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", m["foo"])
+}
+
+func TestDiscoveryNoInstances(t *testing.T) {
+	eureka := &fakeEureka{app: &model.Application{Name: ServiceIdDefault}}
+
+	cfg, _ := New(Bootstrap{Name: "myapp", Discovery: Discovery{Enabled: true, Client: eureka}})
+	_, err := cfg.FetchAsMap()
+	assert.Equal(t, NoServerAvailableErr, err)
+
+	_, err = New(Bootstrap{Name: "myapp", Discovery: Discovery{Enabled: true}})
+	assert.Equal(t, DiscoveryNotDeclaredErr, err)
+}
+
+func TestDiscoveryOAuth2(t *testing.T) {
+	uaa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, secret, _ := r.BasicAuth()
+		w.Write([]byte(`{"access_token": "token-` + secret + `", "expires_in": 3600}`))
+	}))
+	defer uaa.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("authorization: " + r.Header.Get("Authorization") + "\n"))
+	}))
+	defer server.Close()
+
+	eureka := &fakeEureka{app: &model.Application{
+		Name:      ServiceIdDefault,
+		Instances: []*model.Instance{instanceFor(server.URL, model.UP, model.Metadata{"password": "pw"})},
+	}}
+
+	// discovered servers receive the bearer token of each client's own credentials
+	for _, secret := range []string{"s1", "s2"} {
+		cfg, err := New(Bootstrap{Name: "myapp", Discovery: Discovery{Enabled: true, Client: eureka},
+			OAuth2: &OAuth2{ClientId: "shared", ClientSecret: secret, AccessTokenUri: uaa.URL}})
+		assert.NoError(t, err)
+
+		m, err := cfg.FetchAsMap()
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer token-"+secret, m["authorization"])
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Duration is a time.Duration which binds from configuration values such as "30s"
+// or "1h30m".  As with Spring a bare number is interpreted as milliseconds
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+// set assigns the duration from a decoded number of milliseconds or duration string
+func (d *Duration) set(v interface{}) error {
+	switch value := v.(type) {
+	case int:
+		d.Duration = time.Duration(value) * time.Millisecond
+	case float64:
+		d.Duration = time.Duration(value * float64(time.Millisecond))
+	case string:
+		// flattened properties are strings, so numbers arrive quoted
+		if ms, err := strconv.ParseFloat(value, 64); err == nil {
+			d.Duration = time.Duration(ms * float64(time.Millisecond))
+			return nil
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		d.Duration = parsed
+	default:
+		return fmt.Errorf("Invalid duration: %v", v)
+	}
+	return nil
+}
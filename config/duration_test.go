@@ -0,0 +1,54 @@
+package config
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	var v struct {
+		Timeout Duration `json:"timeout"`
+		Retry   Duration `json:"retry"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"timeout": "1m30s", "retry": 250}`), &v))
+	assert.Equal(t, 90*time.Second, v.Timeout.Duration)
+	assert.Equal(t, 250*time.Millisecond, v.Retry.Duration)
+
+	b, _ := json.Marshal(v.Timeout)
+	assert.Equal(t, `"1m30s"`, string(b))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"timeout": "soon"}`), &v))
+}
+
+func TestDurationYAML(t *testing.T) {
+	var v struct {
+		Timeout Duration `yaml:"timeout"`
+		Retry   Duration `yaml:"retry"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte("timeout: 1m30s\nretry: 250\n"), &v))
+	assert.Equal(t, 90*time.Second, v.Timeout.Duration)
+	assert.Equal(t, 250*time.Millisecond, v.Retry.Duration)
+
+	b, _ := yaml.Marshal(v)
+	assert.Equal(t, "timeout: 1m30s\nretry: 250ms\n", string(b))
+
+	assert.Error(t, yaml.Unmarshal([]byte("timeout: soon\n"), &v))
+}
+
+func TestDurationBindProperties(t *testing.T) {
+	var v struct {
+		Timeout Duration `json:"timeout"`
+		Retry   Duration `json:"retry"`
+		Delay   Duration `json:"delay"`
+	}
+	p := Properties{"timeout": "500", "retry": "2s", "delay": "1.5"}
+	assert.NoError(t, bindProperties(p, &v))
+	assert.Equal(t, 500*time.Millisecond, v.Timeout.Duration)
+	assert.Equal(t, 2*time.Second, v.Retry.Duration)
+	assert.Equal(t, 1500*time.Microsecond, v.Delay.Duration)
+
+	assert.Error(t, bindProperties(Properties{"timeout": "soon"}, &v))
+}
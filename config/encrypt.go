@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"github.com/ContainX/go-utils/encoding"
+	"net/http"
+	"strings"
+)
+
+const (
+	// CipherPrefix marks a property value within a configuration repository as
+	// encrypted.  Values with this prefix are decrypted by the server before being served
+	CipherPrefix = "{cipher}"
+
+	// invalidPrefix is added by the config server to keys it failed to decrypt
+	invalidPrefix = "invalid."
+	// notAvailable is the value the config server gives keys it failed to decrypt
+	notAvailable = "<n/a>"
+
+	pathEncrypt       = "encrypt"
+	pathDecrypt       = "decrypt"
+	pathEncryptStatus = "encrypt/status"
+	statusOK          = "OK"
+)
+
+var (
+	EncryptionNotAvailableErr = errors.New("Encryption is not available on the config server")
+)
+
+// EncryptStatus is the response of the config server /encrypt/status endpoint
+type EncryptStatus struct {
+	// Status is "OK" when a key is installed, otherwise a Spring status such as "NO_KEY"
+	Status string `json:"status"`
+	// Description holds the reason when the status is not "OK"
+	Description string `json:"description,omitempty"`
+}
+
+// OK returns true if the server has a usable encryption key
+func (s *EncryptStatus) OK() bool {
+	return s.Status == statusOK
+}
+
+// WithKeyAlias prefixes value with {key:alias} which instructs the config server
+// to use the named key when encrypting or decrypting value
+func WithKeyAlias(alias, value string) string {
+	if alias == "" {
+		return value
+	}
+	return "{key:" + alias + "}" + value
+}
+
+// IsCipher returns true if value is an encrypted property value ({cipher}...)
+func IsCipher(value string) bool {
+	return strings.HasPrefix(value, CipherPrefix)
+}
+
+// Undecryptable returns the original key of a property the config server failed
+// to decrypt.  The server serves such properties as invalid.{key} with the value
+// "<n/a>"
+func Undecryptable(key, value string) (string, bool) {
+	if strings.HasPrefix(key, invalidPrefix) && value == notAvailable {
+		return strings.TrimPrefix(key, invalidPrefix), true
+	}
+	return "", false
+}
+
+func (c *client) Encrypt(plain string) (string, error) {
+	return c.send(http.MethodPost, pathEncrypt, contentTypeText, plain)
+}
+
+func (c *client) Decrypt(cipher string) (string, error) {
+	cipher = strings.TrimPrefix(cipher, CipherPrefix)
+	return c.send(http.MethodPost, pathDecrypt, contentTypeText, cipher)
+}
+
+func (c *client) EncryptStatus() (*EncryptStatus, error) {
+	content, err := c.send(http.MethodGet, pathEncryptStatus, "", "")
+	if err != nil {
+		if se, ok := err.(*ServerError); ok && se.Status != "" {
+			return &EncryptStatus{Status: se.Status, Description: se.Description}, EncryptionNotAvailableErr
+		}
+		return nil, err
+	}
+
+	enc, _ := encoding.NewEncoder(encoding.JSON)
+	status := &EncryptStatus{}
+	if err := enc.UnMarshalStr(content, status); err != nil {
+		return nil, err
+	}
+	if !status.OK() {
+		return status, EncryptionNotAvailableErr
+	}
+	return status, nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func startEncryptServer(keyInstalled bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !keyInstalled {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"description":"No key was installed for encryption service","status":"NO_KEY"}`))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/encrypt/status":
+			w.Write([]byte(`{"status":"OK"}`))
+		case "/encrypt":
+			w.Write([]byte(reverse(string(body))))
+		case "/decrypt":
+			if strings.HasPrefix(string(body), "{") {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"description":"Text not encrypted with this key","status":"INVALID"}`))
+				return
+			}
+			w.Write([]byte(reverse(string(body))))
+		}
+	}))
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	server := startEncryptServer(true)
+	defer server.Close()
+
+	cfg, err := New(Bootstrap{Name: "myapp", URI: server.URL, Username: "user", Password: "secret"})
+	assert.NoError(t, err)
+
+	cipher, err := cfg.Encrypt("mysecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "tercesym", cipher)
+
+	plain, err := cfg.Decrypt(CipherPrefix + cipher)
+	assert.NoError(t, err)
+	assert.Equal(t, "mysecret", plain)
+
+	_, err = cfg.Decrypt(WithKeyAlias("other", cipher))
+	if assert.Error(t, err) {
+		assert.Equal(t, "INVALID", err.(*ServerError).Status)
+	}
+
+	status, err := cfg.EncryptStatus()
+	assert.NoError(t, err)
+	assert.True(t, status.OK())
+}
+
+func TestEncryptStatusNoKey(t *testing.T) {
+	server := startEncryptServer(false)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL, Username: "user", Password: "secret"})
+
+	status, err := cfg.EncryptStatus()
+	assert.Equal(t, EncryptionNotAvailableErr, err)
+	assert.Equal(t, "NO_KEY", status.Status)
+}
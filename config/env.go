@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EnvFormat selects how FetchAsEnv renders properties
+type EnvFormat int
+
+const (
+	// EnvDotenv renders KEY=value lines, double quoting values when required
+	EnvDotenv EnvFormat = iota
+	// EnvExport renders POSIX shell `export KEY='value'` lines suitable for eval or source
+	EnvExport
+	// EnvNull renders unquoted KEY=value entries terminated by a NUL byte (like env -0)
+	EnvNull
+)
+
+// safeEnvChars are the characters which do not require dotenv values to be quoted
+const safeEnvChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-.,:/@%+="
+
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`", "\n", `\n`, "\r", `\r`)
+
+// EnvCollisionError is returned when distinct properties map to the same
+// environment variable name (ex. a.b and a_b both become A_B)
+type EnvCollisionError struct {
+	Name string
+	Keys []string
+}
+
+func (e *EnvCollisionError) Error() string {
+	return fmt.Sprintf("Properties %s all map to environment variable %s", strings.Join(e.Keys, ", "), e.Name)
+}
+
+// EnvName converts a property key into its environment variable form following
+// Spring relaxed binding.  Example: datasource.mysql-user[0] becomes DATASOURCE_MYSQLUSER_0.
+// Names which would start with a digit are prefixed with "_" so they remain valid
+// identifiers
+func EnvName(key string) string {
+	b := &bytes.Buffer{}
+	for _, r := range key {
+		switch {
+		case r == '-' || r == ']':
+			continue
+		case r == '.' || r == '[':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	name := b.String()
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// EnvNames returns the environment variable name of every key in m.  An error is
+// returned when a key has no name or when distinct keys share a name, as one would
+// silently overwrite the other
+func EnvNames(m map[string]string) (map[string]string, error) {
+	names := map[string]string{}
+	keys := map[string][]string{}
+	for _, k := range Properties(m).Keys() {
+		name := EnvName(k)
+		if name == "" {
+			return nil, fmt.Errorf("Property %q has no environment variable name", k)
+		}
+		names[k] = name
+		keys[name] = append(keys[name], k)
+	}
+
+	collisions := []string{}
+	for name, ks := range keys {
+		if len(ks) > 1 {
+			collisions = append(collisions, name)
+		}
+	}
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return nil, &EnvCollisionError{Name: collisions[0], Keys: keys[collisions[0]]}
+	}
+	return names, nil
+}
+
+// Environ converts properties into KEY=value pairs, sorted by key, as used by
+// os/exec.Cmd.Env.  See EnvNames for the errors returned
+func Environ(m map[string]string) ([]string, error) {
+	names, err := EnvNames(m)
+	if err != nil {
+		return nil, err
+	}
+
+	env := []string{}
+	for _, k := range Properties(m).Keys() {
+		env = append(env, names[k]+"="+m[k])
+	}
+	return env, nil
+}
+
+// FormatEnv renders properties as environment variables in the specified format.
+// See EnvNames for the errors returned
+func FormatEnv(m map[string]string, format EnvFormat) (string, error) {
+	names, err := EnvNames(m)
+	if err != nil {
+		return "", err
+	}
+
+	b := &bytes.Buffer{}
+	for _, k := range Properties(m).Keys() {
+		name, value := names[k], m[k]
+		switch format {
+		case EnvExport:
+			b.WriteString("export " + name + "=" + shellQuote(value) + "\n")
+		case EnvNull:
+			b.WriteString(name + "=" + value + "\x00")
+		default:
+			b.WriteString(name + "=" + dotenvQuote(value) + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func (c *client) FetchAsEnv(format EnvFormat) (string, error) {
+	m, err := c.FetchAsMap()
+	if err != nil {
+		return "", err
+	}
+	return FormatEnv(m, format)
+}
+
+// shellQuote single quotes value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+// dotenvQuote double quotes value if it contains characters outside of safeEnvChars
+func dotenvQuote(value string) string {
+	for _, r := range value {
+		if !strings.ContainsRune(safeEnvChars, r) {
+			return `"` + dotenvEscaper.Replace(value) + `"`
+		}
+	}
+	return value
+}
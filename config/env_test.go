@@ -0,0 +1,56 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "DATASOURCE_MYSQL_USER", EnvName("datasource.mysql.user"))
+	assert.Equal(t, "SERVERS_0_HOST", EnvName("servers[0].host"))
+	assert.Equal(t, "MY_CONNECTIONTIMEOUT", EnvName("my.connection-timeout"))
+	assert.Equal(t, "_0_HOST", EnvName("0.host"))
+}
+
+func TestEnvNames(t *testing.T) {
+	names, err := EnvNames(map[string]string{"a.b": "1", "c": "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a.b": "A_B", "c": "C"}, names)
+
+	_, err = EnvNames(map[string]string{"a.b": "1", "a-b": "2", "a_b": "3"})
+	assert.Equal(t, &EnvCollisionError{Name: "A_B", Keys: []string{"a.b", "a_b"}}, err)
+	assert.Equal(t, "Properties a.b, a_b all map to environment variable A_B", err.Error())
+
+	_, err = Environ(map[string]string{"...": "1"})
+	assert.Error(t, err)
+}
+
+func TestFormatEnv(t *testing.T) {
+	m := map[string]string{
+		"datasource.url":  "jdbc:mysql://localhost:3306/db",
+		"datasource.pass": `it's "$ecret"`,
+	}
+	format := func(f EnvFormat) string {
+		env, err := FormatEnv(m, f)
+		assert.NoError(t, err)
+		return env
+	}
+
+	assert.Equal(t, "DATASOURCE_PASS=\"it's \\\"\\$ecret\\\"\"\nDATASOURCE_URL=jdbc:mysql://localhost:3306/db\n",
+		format(EnvDotenv))
+	assert.Equal(t, "export DATASOURCE_PASS='it'\\''s \"$ecret\"'\nexport DATASOURCE_URL='jdbc:mysql://localhost:3306/db'\n",
+		format(EnvExport))
+	assert.Equal(t, "DATASOURCE_PASS=it's \"$ecret\"\x00DATASOURCE_URL=jdbc:mysql://localhost:3306/db\x00",
+		format(EnvNull))
+}
+
+func TestConfigAsEnv(t *testing.T) {
+	server := startServer("datasource.url:  jdbc:mysql://localhost:3306/db\nfoo:  bar\n")
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+
+	env, err := cfg.FetchAsEnv(EnvDotenv)
+	assert.NoError(t, err)
+	assert.Equal(t, "DATASOURCE_URL=jdbc:mysql://localhost:3306/db\nFOO=bar\n", env)
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"github.com/ContainX/go-utils/encoding"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Format is {name}/{profile}/{label}
+	environmentPathFmt = "%s/%s/%s"
+	// labelSlash is how the config server expects slashes within a label to be escaped
+	labelSlash = "(_)"
+)
+
+// Environment is the response of the config server environment endpoint.  It holds
+// the raw property sources, in order of precedence (highest first), along with the
+// version (ex. git commit id) of the repository they were read from
+type Environment struct {
+	Name            string            `json:"name"`
+	Profiles        []string          `json:"profiles"`
+	Label           string            `json:"label"`
+	Version         string            `json:"version"`
+	State           string            `json:"state"`
+	PropertySources []*PropertySource `json:"propertySources"`
+}
+
+// PropertySource is a named set of properties such as a file within the repository
+// (ex. git:https://github.com/org/config/application-prod.yml)
+type PropertySource struct {
+	Name   string                 `json:"name"`
+	Source map[string]interface{} `json:"source"`
+}
+
+func (c *client) FetchEnvironment() (*Environment, error) {
+	label := strings.Replace(c.bootstrap.Label, "/", labelSlash, -1)
+	path := fmt.Sprintf(environmentPathFmt, c.bootstrap.Name, c.resolveProfile(), label)
+
+	content, err := c.send(http.MethodGet, path, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	enc, _ := encoding.NewEncoder(encoding.JSON)
+	env := &Environment{}
+	if err := enc.UnMarshalStr(content, env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
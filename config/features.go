@@ -0,0 +1,158 @@
+package config
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// FeaturesPrefix is the property prefix feature flags are declared under.  A flag
+// is either a boolean (features.search=true) or a group of settings:
+//
+//	features.search.enabled=true        # master switch (default true)
+//	features.search.percentage=25       # rollout to 25% of users
+//	features.search.allow=alice,bob     # always enabled for these users
+//	features.search.deny=mallory        # never enabled for these users
+//
+// allow and deny may also be declared as lists (features.search.allow[0]=alice).
+const FeaturesPrefix = "features"
+
+const (
+	featureEnabled    = "enabled"
+	featurePercentage = "percentage"
+	featureAllow      = "allow"
+	featureDeny       = "deny"
+)
+
+var listIndexRegex = regexp.MustCompile(`\[\d+\]$`)
+
+// Features evaluates feature flags from the latest properties of a config client
+type Features struct {
+	flags atomic.Pointer[map[string]*feature]
+}
+
+type feature struct {
+	enabled    bool
+	percentage int
+	rollout    bool
+	allow      map[string]bool
+	deny       map[string]bool
+}
+
+// NewFeatures creates a Features view of client.  The client is refreshed if it
+// has not been already and flags are rebuilt after every refresh
+func NewFeatures(client ConfigClient) (*Features, error) {
+	p := client.Properties()
+	if p == nil {
+		if _, err := client.Refresh(); err != nil {
+			return nil, err
+		}
+		p = client.Properties()
+	}
+
+	f := &Features{}
+	f.update(p)
+	client.OnRefresh(func(_, new Properties) {
+		f.update(new)
+	})
+	return f, nil
+}
+
+// Enabled returns true if the flag is on.  Flags with a percentage rollout or
+// allow list require a user and are therefore off, use EnabledFor instead
+func (f *Features) Enabled(name string) bool {
+	return f.EnabledFor(name, "")
+}
+
+// EnabledFor returns true if the flag is on for user.  Deny lists take precedence
+// over allow lists, which take precedence over the percentage rollout.  The rollout
+// is stable for a given flag and user
+func (f *Features) EnabledFor(name, user string) bool {
+	ft, ok := (*f.flags.Load())[name]
+	if !ok || !ft.enabled {
+		return false
+	}
+	if user != "" && ft.deny[user] {
+		return false
+	}
+	if user != "" && ft.allow[user] {
+		return true
+	}
+	if ft.rollout {
+		return user != "" && bucket(name, user) < ft.percentage
+	}
+	return len(ft.allow) == 0
+}
+
+// Names returns the declared flag names in sorted order
+func (f *Features) Names() []string {
+	p := Properties{}
+	for name := range *f.flags.Load() {
+		p[name] = ""
+	}
+	return p.Keys()
+}
+
+// update rebuilds the flags from p and swaps them in
+func (f *Features) update(p Properties) {
+	flags := map[string]*feature{}
+	get := func(name string) *feature {
+		if ft, ok := flags[name]; ok {
+			return ft
+		}
+		ft := &feature{enabled: true, allow: map[string]bool{}, deny: map[string]bool{}}
+		flags[name] = ft
+		return ft
+	}
+
+	for k, v := range p {
+		if !strings.HasPrefix(k, FeaturesPrefix+".") {
+			continue
+		}
+		path := listIndexRegex.ReplaceAllString(strings.TrimPrefix(k, FeaturesPrefix+"."), "")
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			get(path).enabled = parseBool(v)
+			continue
+		}
+
+		name, setting := path[:i], path[i+1:]
+		switch setting {
+		case featureEnabled:
+			get(name).enabled = parseBool(v)
+		case featurePercentage:
+			ft := get(name)
+			ft.rollout = true
+			ft.percentage, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(v), "%"))
+		case featureAllow:
+			addUsers(get(name).allow, v)
+		case featureDeny:
+			addUsers(get(name).deny, v)
+		default:
+			get(path).enabled = parseBool(v)
+		}
+	}
+	f.flags.Store(&flags)
+}
+
+// bucket maps a flag and user onto 0-99
+func bucket(name, user string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + user))
+	return int(h.Sum32() % 100)
+}
+
+func addUsers(users map[string]bool, value string) {
+	for _, u := range strings.Split(value, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			users[u] = true
+		}
+	}
+}
+
+func parseBool(value string) bool {
+	b, _ := strconv.ParseBool(strings.TrimSpace(value))
+	return b
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const featuresProps = `features.search:  true
+features.legacy:  false
+features.beta.percentage:  30
+features.beta.deny[0]:  mallory
+features.beta.allow[0]:  alice
+features.beta.allow[1]:  bob
+features.pilot.allow:  carol, dave
+features.off.enabled:  false
+features.off.allow:  alice
+`
+
+func TestFeatures(t *testing.T) {
+	server := startServer(featuresProps)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	f, err := NewFeatures(cfg)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"beta", "legacy", "off", "pilot", "search"}, f.Names())
+	assert.True(t, f.Enabled("search"))
+	assert.False(t, f.Enabled("legacy"))
+	assert.False(t, f.Enabled("missing"))
+	assert.False(t, f.EnabledFor("off", "alice"))
+
+	assert.True(t, f.EnabledFor("pilot", "carol"))
+	assert.False(t, f.EnabledFor("pilot", "erin"))
+	assert.False(t, f.Enabled("pilot"))
+
+	assert.True(t, f.EnabledFor("beta", "alice"))
+	assert.False(t, f.EnabledFor("beta", "mallory"))
+	assert.False(t, f.Enabled("beta"))
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		if f.EnabledFor("beta", user) {
+			enabled++
+		}
+		assert.Equal(t, f.EnabledFor("beta", user), f.EnabledFor("beta", user))
+	}
+	assert.InDelta(t, 300, enabled, 60)
+}
+
+func TestFeaturesRefresh(t *testing.T) {
+	server := startChangingServer("features.search:  false\n", "features.search:  true\n")
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	f, _ := NewFeatures(cfg)
+	assert.False(t, f.Enabled("search"))
+
+	cfg.Refresh()
+	assert.True(t, f.Enabled("search"))
+}
@@ -0,0 +1,296 @@
+// Package gen generates Go struct definitions mirroring the shape of an application's
+// configuration so Fetch targets stay in sync with the configuration repository.
+// Field types are inferred from the values: integers, floats, booleans, durations
+// ("30s" becomes config.Duration), lists and nested objects.  Whether a number is an
+// integer or a float follows its source, so documents should be decoded with
+// DecodeJSON or DecodeYAML which keep 1 and 1.0 distinct.
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"gopkg.in/yaml.v2"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// TypeNameDefault is the name of the root struct when none is specified
+	TypeNameDefault = "Config"
+
+	durationType = "config.Duration"
+	configImport = "github.com/ContainX/go-springcloud/config"
+	listSuffix   = "[]"
+	itemSuffix   = "Item"
+	anyType      = "interface{}"
+)
+
+var (
+	PackageNotDeclaredErr = errors.New("Package name must be declared")
+
+	durationRegex = regexp.MustCompile(`^(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+$`)
+)
+
+// Options controls the generated source
+type Options struct {
+	// Package is the package clause of the generated file.
+	Package string
+	// TypeName is the root struct name (default Config).  Nested structs are named
+	// by appending their path (ex. ConfigDatasource).
+	TypeName string
+	// Source describes where the configuration came from and is written to the header.
+	Source string
+}
+
+type field struct {
+	name string
+	key  string
+	path string
+	typ  string
+}
+
+type structType struct {
+	name   string
+	fields []*field
+}
+
+type generator struct {
+	types       []*structType
+	names       map[string]bool
+	useDuration bool
+}
+
+// DecodeJSON decodes a JSON configuration document keeping numbers as json.Number
+// so integers and floats can be told apart
+func DecodeJSON(b []byte) (map[string]interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	tree := map[string]interface{}{}
+	if err := d.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// DecodeYAML decodes a YAML configuration document.  Integers decode as int and
+// floats as float64
+func DecodeYAML(b []byte) (map[string]interface{}, error) {
+	tree := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// Generate returns formatted Go source declaring structs for tree, a decoded JSON
+// or YAML configuration document
+func Generate(tree map[string]interface{}, opts Options) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, PackageNotDeclaredErr
+	}
+	if opts.TypeName == "" {
+		opts.TypeName = TypeNameDefault
+	}
+
+	g := &generator{names: map[string]bool{}}
+	g.structFor(opts.TypeName, "", tree)
+
+	b := &bytes.Buffer{}
+	fmt.Fprintln(b, "// Code generated by springcloud config gen. DO NOT EDIT.")
+	if opts.Source != "" {
+		fmt.Fprintf(b, "// Source: %s\n", opts.Source)
+	}
+	fmt.Fprintf(b, "\npackage %s\n\n", opts.Package)
+	if g.useDuration {
+		fmt.Fprintf(b, "import %q\n\n", configImport)
+	}
+
+	for _, t := range g.types {
+		fmt.Fprintf(b, "type %s struct {\n", t.name)
+		for _, f := range t.fields {
+			fmt.Fprintf(b, "\t%s %s `json:\"%s\" property:\"%s\"`\n", f.name, f.typ, f.key, f.path)
+		}
+		fmt.Fprint(b, "}\n\n")
+	}
+	return format.Source(b.Bytes())
+}
+
+// structFor declares a struct named name for m and returns the type name
+func (g *generator) structFor(name, path string, m map[string]interface{}) string {
+	name = g.uniqueName(name)
+	t := &structType{name: name}
+	g.types = append(g.types, t)
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fieldNames := map[string]bool{}
+	for _, k := range keys {
+		fieldName := Identifier(k)
+		for i := 2; fieldNames[fieldName]; i++ {
+			fieldName = fmt.Sprintf("%s%d", Identifier(k), i)
+		}
+		fieldNames[fieldName] = true
+
+		p := joinPath(path, k)
+		t.fields = append(t.fields, &field{
+			name: fieldName,
+			key:  k,
+			path: p,
+			typ:  g.typeOf(name+fieldName, p, m[k]),
+		})
+	}
+	return name
+}
+
+// typeOf infers the Go type of value, declaring structs for objects
+func (g *generator) typeOf(name, path string, value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return g.structFor(name, path, v)
+	case map[interface{}]interface{}:
+		return g.typeOf(name, path, stringKeys(v))
+	case []interface{}:
+		return "[]" + g.elemType(name+itemSuffix, path+listSuffix, v)
+	case bool:
+		return "bool"
+	case float32, float64:
+		return "float64"
+	case int, int64, uint64:
+		return "int"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "int"
+		}
+		return "float64"
+	case string:
+		if isDuration(v) {
+			g.useDuration = true
+			return durationType
+		}
+		return "string"
+	}
+	return anyType
+}
+
+// elemType infers the element type of a list.  Objects are merged into a single
+// struct holding the union of their fields
+func (g *generator) elemType(name, path string, list []interface{}) string {
+	if len(list) == 0 {
+		return anyType
+	}
+
+	merged := map[string]interface{}{}
+	objects := true
+	for _, e := range list {
+		if im, ok := e.(map[interface{}]interface{}); ok {
+			e = stringKeys(im)
+		}
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			objects = false
+			break
+		}
+		for k, v := range m {
+			if merged[k] == nil {
+				merged[k] = v
+			}
+		}
+	}
+	if objects {
+		return g.structFor(name, path, merged)
+	}
+
+	typ := ""
+	for _, e := range list {
+		t := scalarType(e)
+		if typ != "" && t != typ {
+			// mixed ints and floats are floats, anything else is untyped
+			if (typ == "int" && t == "float64") || (typ == "float64" && t == "int") {
+				typ = "float64"
+				continue
+			}
+			return anyType
+		}
+		typ = t
+	}
+	if typ == durationType {
+		g.useDuration = true
+	}
+	return typ
+}
+
+// scalarType infers the type of a list element without declaring structs
+func scalarType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}, nil:
+		return anyType
+	}
+	g := &generator{names: map[string]bool{}}
+	return g.typeOf("", "", value)
+}
+
+func (g *generator) uniqueName(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+// Identifier converts a configuration key into an exported Go identifier
+// (ex. max-connections becomes MaxConnections)
+func Identifier(key string) string {
+	parts := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	b := &strings.Builder{}
+	for _, p := range parts {
+		r := []rune(p)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	id := b.String()
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
+
+func isDuration(s string) bool {
+	if !durationRegex.MatchString(s) {
+		return false
+	}
+	_, err := time.ParseDuration(s)
+	return err == nil
+}
+
+func joinPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return path + "[" + key + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func stringKeys(m map[interface{}]interface{}) map[string]interface{} {
+	converted := map[string]interface{}{}
+	for k, v := range m {
+		converted[fmt.Sprint(k)] = v
+	}
+	return converted
+}
@@ -0,0 +1,96 @@
+package gen
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const testConfig = `{
+  "name": "myapp",
+  "max-connections": 20,
+  "ratio": 0.75,
+  "scale": 1.0,
+  "debug": true,
+  "timeout": "30s",
+  "tags": ["a", "b"],
+  "datasource": {"url": "jdbc:mysql://db/app", "pool": {"size": 10}},
+  "servers": [{"host": "a"}, {"host": "b", "port": 8080}]
+}`
+
+// expectedSource uses ~ in place of the backquotes around struct tags
+const expectedSource = `// Code generated by springcloud config gen. DO NOT EDIT.
+// Source: myapp-default
+
+package settings
+
+import "github.com/ContainX/go-springcloud/config"
+
+type Config struct {
+	Datasource     ConfigDatasource    ~json:"datasource" property:"datasource"~
+	Debug          bool                ~json:"debug" property:"debug"~
+	MaxConnections int                 ~json:"max-connections" property:"max-connections"~
+	Name           string              ~json:"name" property:"name"~
+	Ratio          float64             ~json:"ratio" property:"ratio"~
+	Scale          float64             ~json:"scale" property:"scale"~
+	Servers        []ConfigServersItem ~json:"servers" property:"servers"~
+	Tags           []string            ~json:"tags" property:"tags"~
+	Timeout        config.Duration     ~json:"timeout" property:"timeout"~
+}
+
+type ConfigDatasource struct {
+	Pool ConfigDatasourcePool ~json:"pool" property:"datasource.pool"~
+	Url  string               ~json:"url" property:"datasource.url"~
+}
+
+type ConfigDatasourcePool struct {
+	Size int ~json:"size" property:"datasource.pool.size"~
+}
+
+type ConfigServersItem struct {
+	Host string ~json:"host" property:"servers[].host"~
+	Port int    ~json:"port" property:"servers[].port"~
+}
+`
+
+func TestGenerate(t *testing.T) {
+	tree, err := DecodeJSON([]byte(testConfig))
+	assert.NoError(t, err)
+
+	src, err := Generate(tree, Options{Package: "settings", Source: "myapp-default"})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(expectedSource, "~", "`"), string(src))
+}
+
+func TestGenerateYAML(t *testing.T) {
+	tree, err := DecodeYAML([]byte("pool:\n  size: 10\n  ratio: 1.0\n"))
+	assert.NoError(t, err)
+
+	src, err := Generate(tree, Options{Package: "settings"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "Ratio float64")
+	assert.Contains(t, string(src), "Size  int")
+}
+
+func TestGeneratePackageRequired(t *testing.T) {
+	_, err := Generate(map[string]interface{}{}, Options{})
+	assert.Equal(t, PackageNotDeclaredErr, err)
+}
+
+func TestIdentifier(t *testing.T) {
+	assert.Equal(t, "MaxConnections", Identifier("max-connections"))
+	assert.Equal(t, "ApiKey", Identifier("api_key"))
+	assert.Equal(t, "X8080", Identifier("8080"))
+}
+
+func TestElemType(t *testing.T) {
+	g := &generator{names: map[string]bool{}}
+	assert.Equal(t, "float64", g.elemType("X", "x[]", []interface{}{1.0, 1.5}))
+	assert.Equal(t, "float64", g.elemType("X", "x[]", []interface{}{1, 2.5}))
+	assert.Equal(t, "int", g.elemType("X", "x[]", []interface{}{json.Number("1"), json.Number("2")}))
+	assert.Equal(t, "interface{}", g.elemType("X", "x[]", []interface{}{1.0, "a"}))
+	assert.Equal(t, "interface{}", g.elemType("X", "x[]", []interface{}{}))
+	assert.Equal(t, "config.Duration", g.elemType("X", "x[]", []interface{}{"1s", "5m"}))
+	assert.True(t, g.useDuration)
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ContainX/go-utils/encoding"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// HistorySizeDefault is the number of snapshots kept when none is specified
+	HistorySizeDefault = 10
+	// Format is {dir}/{name}-{profile}-{label}.history.json
+	historyFileFmt = "%s-%s-%s.history.json"
+	// hashVersionLen is the length of versions derived from the properties
+	hashVersionLen = 12
+)
+
+var (
+	VersionNotFoundErr = errors.New("Version was not found in the configuration history")
+)
+
+// History holds the settings for the configuration history
+type History struct {
+	// Size is the number of snapshots kept (default 10).
+	Size int `json:"size"`
+
+	// Dir persists the history to disk when set so it survives restarts.  Files hold
+	// unmasked values and are written with owner only permissions.
+	Dir string `json:"dir,omitempty"`
+}
+
+// ChangeType describes how a property differs between two configurations
+type ChangeType string
+
+const (
+	Added   ChangeType = "added"
+	Removed ChangeType = "removed"
+	Changed ChangeType = "changed"
+)
+
+// PropertyChange is a single difference between two configurations
+type PropertyChange struct {
+	Key  string     `json:"key"`
+	Type ChangeType `json:"type"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+// historyState is the persisted form of the history along with any pin
+type historyState struct {
+	Snapshots []*Snapshot `json:"snapshots"`
+	Pinned    *Snapshot   `json:"pinned,omitempty"`
+}
+
+// Snapshot is a configuration fetched by a refresh
+type Snapshot struct {
+	// Version identifies the properties.  It is a hash of the properties as applied,
+	// so the same configuration always has the same version.
+	Version    string     `json:"version"`
+	Timestamp  time.Time  `json:"timestamp"`
+	Properties Properties `json:"properties"`
+}
+
+// DiffProperties returns the changes, sorted by key, required to turn a into b
+func DiffProperties(a, b Properties) []PropertyChange {
+	changes := []PropertyChange{}
+	for _, k := range changedKeys(a, b) {
+		ov, inA := a[k]
+		nv, inB := b[k]
+		switch {
+		case !inA:
+			changes = append(changes, PropertyChange{Key: k, Type: Added, New: nv})
+		case !inB:
+			changes = append(changes, PropertyChange{Key: k, Type: Removed, Old: ov})
+		default:
+			changes = append(changes, PropertyChange{Key: k, Type: Changed, Old: ov, New: nv})
+		}
+	}
+	return changes
+}
+
+func (c *client) History() []*Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]*Snapshot{}, c.history...)
+}
+
+func (c *client) Diff(a, b string) ([]PropertyChange, error) {
+	sa, err := c.snapshot(a)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := c.snapshot(b)
+	if err != nil {
+		return nil, err
+	}
+	return DiffProperties(sa.Properties, sb.Properties), nil
+}
+
+func (c *client) Pin(version string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	s, err := c.snapshot(version)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.pinned = s
+	c.mu.Unlock()
+
+	log.Infof("Pinned configuration to version %s", s.Version)
+	c.apply(s.Properties)
+	c.persistHistory()
+	return nil
+}
+
+func (c *client) Unpin() {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.Lock()
+	c.pinned = nil
+	var latest *Snapshot
+	if len(c.history) > 0 {
+		latest = c.history[len(c.history)-1]
+	}
+	c.mu.Unlock()
+
+	if latest != nil {
+		log.Infof("Unpinned configuration, applying version %s", latest.Version)
+		c.apply(latest.Properties)
+	}
+	c.persistHistory()
+}
+
+func (c *client) Pinned() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.pinned == nil {
+		return ""
+	}
+	return c.pinned.Version
+}
+
+// snapshot returns the most recent snapshot with version
+func (c *client) snapshot(version string) (*Snapshot, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for i := len(c.history) - 1; i >= 0; i-- {
+		if c.history[i].Version == version {
+			return c.history[i], nil
+		}
+	}
+	return nil, VersionNotFoundErr
+}
+
+// version returns the version of the properties p, a truncated hash of their
+// unmasked form
+func version(p Properties) string {
+	sum := sha1.Sum([]byte(p.Format(&Sanitizer{})))
+	return hex.EncodeToString(sum[:])[:hashVersionLen]
+}
+
+// record appends p to the history if it differs from the latest snapshot, trimming
+// the history to the configured size and persisting it when a directory is declared
+func (c *client) record(version string, p Properties) {
+	c.mu.Lock()
+	if n := len(c.history); n > 0 && c.history[n-1].Version == version && len(changedKeys(c.history[n-1].Properties, p)) == 0 {
+		c.mu.Unlock()
+		return
+	}
+
+	c.history = append(c.history, &Snapshot{Version: version, Timestamp: time.Now(), Properties: p})
+	c.history = trimHistory(c.history, c.bootstrap.History.Size)
+	c.mu.Unlock()
+
+	c.persistHistory()
+}
+
+// trimHistory returns the most recent size snapshots of history
+func trimHistory(history []*Snapshot, size int) []*Snapshot {
+	if size <= 0 {
+		size = HistorySizeDefault
+	}
+	if len(history) > size {
+		return append([]*Snapshot{}, history[len(history)-size:]...)
+	}
+	return history
+}
+
+// historyFile returns the path history is persisted to or "" if disabled
+func (c *client) historyFile() string {
+	if c.bootstrap.History.Dir == "" {
+		return ""
+	}
+	name := fmt.Sprintf(historyFileFmt, c.bootstrap.Name, c.resolveProfile(), c.bootstrap.Label)
+	return filepath.Join(c.bootstrap.History.Dir, filepath.Base(name))
+}
+
+// loadHistory reads the persisted history if it exists, trimming it to the configured
+// size and applying the pinned snapshot if the client was pinned
+func (c *client) loadHistory() error {
+	filename := c.historyFile()
+	if filename == "" {
+		return nil
+	}
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil
+	}
+
+	enc, _ := encoding.NewEncoder(encoding.JSON)
+	state := &historyState{}
+	if err := enc.UnMarshalFile(filename, state); err != nil {
+		return err
+	}
+	c.history = trimHistory(state.Snapshots, c.bootstrap.History.Size)
+	if state.Pinned != nil {
+		c.pinned = state.Pinned
+		log.Infof("Restored pin to configuration version %s", state.Pinned.Version)
+		c.apply(state.Pinned.Properties)
+	}
+	return nil
+}
+
+// persistHistory saves the history and pin, logging any error
+func (c *client) persistHistory() {
+	c.mu.RLock()
+	state := &historyState{Snapshots: c.history, Pinned: c.pinned}
+	c.mu.RUnlock()
+
+	if err := c.saveHistory(state); err != nil {
+		log.Errorf("Error saving configuration history: %s", err.Error())
+	}
+}
+
+// saveHistory writes state to disk.  The file is only readable by the owner since
+// snapshots hold unmasked values
+func (c *client) saveHistory(state *historyState) error {
+	filename := c.historyFile()
+	if filename == "" {
+		return nil
+	}
+
+	enc, _ := encoding.NewEncoder(encoding.JSON)
+	content, err := enc.MarshalIndent(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(content), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+// startRevisionServer serves the properties of the current revision
+func startRevisionServer(revision *int32, bodies []string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(bodies[atomic.LoadInt32(revision)]))
+	}))
+}
+
+func TestHistoryPinAndDiff(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "history")
+	defer os.RemoveAll(dir)
+
+	revision := int32(0)
+	server := startRevisionServer(&revision, []string{"foo:  bar\n", "foo:  baz\nnew:  1\n", "foo:  broken\n"})
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL, History: History{Size: 2, Dir: dir}})
+	for i := int32(0); i < 3; i++ {
+		atomic.StoreInt32(&revision, i)
+		cfg.Refresh()
+	}
+
+	c1 := version(Properties{"foo": "bar"})
+	c2 := version(Properties{"foo": "baz", "new": "1"})
+	c3 := version(Properties{"foo": "broken"})
+	history := cfg.History()
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, c2, history[0].Version)
+		assert.Equal(t, c3, history[1].Version)
+	}
+	assert.Equal(t, "broken", cfg.Properties()["foo"])
+
+	changes, err := cfg.Diff(c2, c3)
+	assert.NoError(t, err)
+	assert.Equal(t, []PropertyChange{
+		{Key: "foo", Type: Changed, Old: "baz", New: "broken"},
+		{Key: "new", Type: Removed, Old: "1"},
+	}, changes)
+
+	assert.Equal(t, VersionNotFoundErr, cfg.Pin(c1))
+	assert.NoError(t, cfg.Pin(c2))
+	assert.Equal(t, c2, cfg.Pinned())
+	assert.Equal(t, "baz", cfg.Properties()["foo"])
+
+	changed, err := cfg.Refresh()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "baz", cfg.Properties()["foo"])
+
+	// the fetch methods are not affected by the pin
+	m, err := cfg.FetchAsMap()
+	assert.NoError(t, err)
+	assert.Equal(t, "broken", m["foo"])
+
+	// the pin is restored from disk and trimmed history keeps the most recent snapshots
+	restored, _ := New(Bootstrap{Name: "myapp", URI: server.URL, History: History{Size: 1, Dir: dir}})
+	assert.Equal(t, c2, restored.Pinned())
+	assert.Equal(t, "baz", restored.Properties()["foo"])
+	if history := restored.History(); assert.Len(t, history, 1) {
+		assert.Equal(t, c3, history[0].Version)
+	}
+
+	cfg.Unpin()
+	assert.Equal(t, "broken", cfg.Properties()["foo"])
+
+	// history is restored from disk
+	restored, _ = New(Bootstrap{Name: "myapp", URI: server.URL, History: History{Dir: dir}})
+	assert.Len(t, restored.History(), 2)
+	assert.Equal(t, "", restored.Pinned())
+	assert.NoError(t, restored.Pin(c2))
+}
+
+func TestHistoryFileUsesResolvedProfile(t *testing.T) {
+	t.Setenv(EnvConfigProfile, "prod")
+
+	c := &client{bootstrap: &Bootstrap{Name: "myapp", Label: "master", History: History{Dir: "/tmp/history"}}}
+	assert.Equal(t, "/tmp/history/myapp-prod-master.history.json", c.historyFile())
+}
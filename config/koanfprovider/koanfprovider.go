@@ -0,0 +1,119 @@
+// Package koanfprovider exposes Spring Cloud Config as a koanf Provider:
+//
+//	k := koanf.New(".")
+//	p := koanfprovider.Provider(client, 30*time.Second)
+//	if err := k.Load(p, nil); err != nil { ... }
+//
+//	p.Watch(func(event interface{}, err error) {
+//		if err == nil {
+//			k.Load(p, nil)
+//		}
+//	})
+package koanfprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/knadh/koanf/v2"
+	"sync"
+	"time"
+)
+
+// IntervalDefault is how often the configuration is refreshed while watching
+// when no interval is specified
+const IntervalDefault = 30 * time.Second
+
+var (
+	AlreadyWatchingErr = errors.New("Configuration is already being watched")
+	NotWatchingErr     = errors.New("Configuration is not being watched")
+)
+
+var _ koanf.Provider = (*ConfigProvider)(nil)
+
+// ConfigProvider loads the configuration of a config client into koanf
+type ConfigProvider struct {
+	client   config.ConfigClient
+	interval time.Duration
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// Provider returns a koanf provider for client refreshing every interval while
+// watched (default 30s)
+func Provider(client config.ConfigClient, interval time.Duration) *ConfigProvider {
+	if interval <= 0 {
+		interval = IntervalDefault
+	}
+	return &ConfigProvider{client: client, interval: interval}
+}
+
+// ReadBytes returns the configuration as JSON for use with the koanf json parser
+func (p *ConfigProvider) ReadBytes() ([]byte, error) {
+	tree, err := p.Read()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tree)
+}
+
+// Read fetches the configuration as nested maps.  While the client is pinned the
+// pinned properties are returned instead
+func (p *ConfigProvider) Read() (map[string]interface{}, error) {
+	if p.client.Pinned() != "" {
+		return config.Unflatten(p.client.Properties())
+	}
+	return p.client.FetchAsTree()
+}
+
+// Watch refreshes the configuration every interval and calls cb when it changes,
+// or with the error when a refresh fails, until Unwatch is called.  The callback
+// is expected to reload the provider and may call Unwatch
+func (p *ConfigProvider) Watch(cb func(event interface{}, err error)) error {
+	p.mu.Lock()
+	if p.stop != nil {
+		p.mu.Unlock()
+		return AlreadyWatchingErr
+	}
+	stop := make(chan struct{})
+	p.stop = stop
+	p.mu.Unlock()
+
+	// the first refresh establishes the baseline the changes are detected from
+	if _, err := p.client.Refresh(); err != nil {
+		cb(nil, err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			changed, err := p.client.Refresh()
+			if err != nil {
+				cb(nil, err)
+			} else if changed {
+				cb(nil, nil)
+			}
+		}
+	}()
+	return nil
+}
+
+// Unwatch stops watching the configuration
+func (p *ConfigProvider) Unwatch() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop == nil {
+		return NotWatchingErr
+	}
+	close(p.stop)
+	p.stop = nil
+	return nil
+}
@@ -0,0 +1,96 @@
+package koanfprovider
+
+import (
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProviderWatch(t *testing.T) {
+	body := atomic.Value{}
+	body.Store("datasource.url: jdbc:mysql://db/app\nservers[0].host: a\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	client, err := config.New(config.Bootstrap{Name: "myapp", URI: server.URL})
+	assert.NoError(t, err)
+
+	k := koanf.New(".")
+	p := Provider(client, 10*time.Millisecond)
+	assert.NoError(t, k.Load(p, nil))
+	assert.Equal(t, "jdbc:mysql://db/app", k.String("datasource.url"))
+	assert.Equal(t, "a", k.Slices("servers")[0].String("host"))
+
+	events := make(chan error, 1)
+	assert.NoError(t, p.Watch(func(event interface{}, err error) {
+		select {
+		case events <- err:
+		default:
+		}
+	}))
+	assert.Equal(t, AlreadyWatchingErr, p.Watch(func(interface{}, error) {}))
+
+	body.Store("datasource.url: jdbc:mysql://db2/app\n")
+	select {
+	case err := <-events:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Expected a change event")
+	}
+	assert.NoError(t, p.Unwatch())
+	assert.Equal(t, NotWatchingErr, p.Unwatch())
+
+	assert.NoError(t, k.Load(p, nil))
+	assert.Equal(t, "jdbc:mysql://db2/app", k.String("datasource.url"))
+}
+
+func TestProviderReadPinned(t *testing.T) {
+	body := atomic.Value{}
+	body.Store("foo: bar\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	client, _ := config.New(config.Bootstrap{Name: "myapp", URI: server.URL})
+	client.Refresh()
+	assert.NoError(t, client.Pin(client.History()[0].Version))
+
+	body.Store("foo: baz\n")
+	k := koanf.New(".")
+	assert.NoError(t, k.Load(Provider(client, 0), nil))
+	assert.Equal(t, "bar", k.String("foo"))
+}
+
+func TestUnwatchFromCallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, _ := config.New(config.Bootstrap{Name: "myapp", URI: server.URL})
+	p := Provider(client, 10*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// the failed initial refresh calls back before Watch returns
+		assert.NoError(t, p.Watch(func(event interface{}, err error) {
+			assert.Error(t, err)
+			p.Unwatch()
+		}))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Unwatch from the callback deadlocked")
+	}
+	assert.Equal(t, NotWatchingErr, p.Unwatch())
+}
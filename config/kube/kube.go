@@ -0,0 +1,214 @@
+// Package kube renders the configuration of an application as Kubernetes ConfigMap
+// and Secret manifests.  Values which are sensitive (see config.Sanitizer), listed
+// in Options.SecretKeys or still encrypted ({cipher}) are placed in the Secret, the
+// remainder in the ConfigMap.  Rendering only uses the fetched properties and needs
+// no access to a cluster.
+//
+// A config server which decrypts properties itself serves them without their
+// {cipher} marker, so those values are only recognised as secrets by their key.
+// Declare the keys of such properties in Options.SecretKeys when their names do not
+// look sensitive.
+//
+// Encryption keys are held by the config server, so {cipher} values are decrypted
+// by it: Generate sends each one to the server's /decrypt endpoint, which must be
+// enabled and reachable.
+package kube
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// KeyFormat selects how property keys are converted into manifest data keys
+type KeyFormat int
+
+const (
+	// KeyEnv uses the environment variable form (DATASOURCE_URL) for use with envFrom
+	KeyEnv KeyFormat = iota
+	// KeyProperty keeps the property form (datasource.url) for use as mounted files
+	KeyProperty
+)
+
+const (
+	labelName      = "app.kubernetes.io/name"
+	labelManagedBy = "app.kubernetes.io/managed-by"
+	managedBy      = "springcloud"
+	secretSuffix   = "-secrets"
+)
+
+var (
+	NameNotDeclaredErr = errors.New("Manifest name must be declared")
+
+	// invalidKeyChars are not permitted in ConfigMap and Secret data keys
+	invalidKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]+`)
+)
+
+// KeyCollisionError is returned when distinct properties map to the same data key
+// (ex. a.b and a_b both become A_B)
+type KeyCollisionError struct {
+	Key        string
+	Properties []string
+}
+
+func (e *KeyCollisionError) Error() string {
+	return fmt.Sprintf("Properties %s all map to data key %s", strings.Join(e.Properties, ", "), e.Key)
+}
+
+// Options controls the generated manifests
+type Options struct {
+	// Name of the ConfigMap.
+	Name string
+	// Namespace of the manifests, omitted when empty.
+	Namespace string
+	// SecretName is the name of the Secret (default {Name}-secrets).
+	SecretName string
+	// KeyFormat of the data keys (default KeyEnv).
+	KeyFormat KeyFormat
+	// Sanitizer identifies sensitive keys (default config.DefaultSanitizer).
+	Sanitizer *config.Sanitizer
+	// SecretKeys are property keys always placed in the Secret.
+	SecretKeys []string
+}
+
+// Decrypter decrypts a {cipher} value, typically config.ConfigClient.Decrypt
+type Decrypter func(cipher string) (string, error)
+
+// Split divides props into plain data and secrets.  {cipher} values are decrypted
+// with decrypt (a request to the config server when it is ConfigClient.Decrypt) and
+// treated as secrets along with the SecretKeys and keys matched by the sanitizer.
+// Returned maps are keyed by the data key format of opts.  An error is returned for
+// properties the config server could not decrypt and a KeyCollisionError when
+// properties map to the same data key
+func Split(props map[string]string, decrypt Decrypter, opts Options) (data, secrets map[string]string, err error) {
+	sanitizer := opts.Sanitizer
+	if sanitizer == nil {
+		sanitizer = config.DefaultSanitizer
+	}
+	secretKeys := map[string]bool{}
+	for _, k := range opts.SecretKeys {
+		secretKeys[k] = true
+	}
+
+	data, secrets = map[string]string{}, map[string]string{}
+	origins := map[string]string{}
+	for _, k := range config.Properties(props).Keys() {
+		v := props[k]
+		if original, ok := config.Undecryptable(k, v); ok {
+			return nil, nil, fmt.Errorf("The config server could not decrypt %s", original)
+		}
+
+		key := dataKey(k, opts.KeyFormat)
+		if origin, ok := origins[key]; ok {
+			return nil, nil, &KeyCollisionError{Key: key, Properties: []string{origin, k}}
+		}
+		origins[key] = k
+
+		switch {
+		case config.IsCipher(v):
+			if v, err = decrypt(v); err != nil {
+				return nil, nil, fmt.Errorf("Error decrypting %s: %s", k, err.Error())
+			}
+			secrets[key] = v
+		case secretKeys[k] || sanitizer.IsSensitive(k):
+			secrets[key] = v
+		default:
+			data[key] = v
+		}
+	}
+	return data, secrets, nil
+}
+
+// Render writes the ConfigMap and, if there are secrets, the Secret as a multi
+// document YAML stream
+func Render(w io.Writer, data, secrets map[string]string, opts Options) error {
+	if opts.Name == "" {
+		return NameNotDeclaredErr
+	}
+
+	b := &bytes.Buffer{}
+	writeHeader(b, "ConfigMap", opts.Name, opts)
+	writeData(b, data, func(v string) string { return v })
+
+	if len(secrets) > 0 {
+		secretName := opts.SecretName
+		if secretName == "" {
+			secretName = opts.Name + secretSuffix
+		}
+		b.WriteString("---\n")
+		writeHeader(b, "Secret", secretName, opts)
+		b.WriteString("type: Opaque\n")
+		writeData(b, secrets, func(v string) string {
+			return base64.StdEncoding.EncodeToString([]byte(v))
+		})
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// Generate fetches the configuration of client and renders its manifests.  {cipher}
+// values are decrypted by the config server
+func Generate(w io.Writer, client config.ConfigClient, opts Options) error {
+	props, err := client.FetchAsMap()
+	if err != nil {
+		return err
+	}
+	data, secrets, err := Split(props, client.Decrypt, opts)
+	if err != nil {
+		return err
+	}
+	return Render(w, data, secrets, opts)
+}
+
+func writeHeader(b *bytes.Buffer, kind, name string, opts Options) {
+	b.WriteString("apiVersion: v1\n")
+	b.WriteString("kind: " + kind + "\n")
+	b.WriteString("metadata:\n")
+	b.WriteString("  name: " + quote(name) + "\n")
+	if opts.Namespace != "" {
+		b.WriteString("  namespace: " + quote(opts.Namespace) + "\n")
+	}
+	b.WriteString("  labels:\n")
+	b.WriteString("    " + labelName + ": " + quote(opts.Name) + "\n")
+	b.WriteString("    " + labelManagedBy + ": " + managedBy + "\n")
+}
+
+func writeData(b *bytes.Buffer, m map[string]string, encode func(string) string) {
+	if len(m) == 0 {
+		b.WriteString("data: {}\n")
+		return
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteString("data:\n")
+	for _, k := range keys {
+		b.WriteString("  " + quote(k) + ": " + quote(encode(m[k])) + "\n")
+	}
+}
+
+// dataKey converts a property key into a valid data key
+func dataKey(key string, format KeyFormat) string {
+	if format == KeyEnv {
+		return config.EnvName(key)
+	}
+	key = strings.Replace(strings.Replace(key, "[", ".", -1), "]", "", -1)
+	return invalidKeyChars.ReplaceAllString(key, "_")
+}
+
+// quote renders s as a YAML double quoted scalar
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
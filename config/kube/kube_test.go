@@ -0,0 +1,88 @@
+package kube
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const expectedManifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: "myapp"
+  namespace: "prod"
+  labels:
+    app.kubernetes.io/name: "myapp"
+    app.kubernetes.io/managed-by: springcloud
+data:
+  "DATASOURCE_URL": "jdbc:mysql://db/app"
+  "SERVERS_0_HOST": "a"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: "myapp-secrets"
+  namespace: "prod"
+  labels:
+    app.kubernetes.io/name: "myapp"
+    app.kubernetes.io/managed-by: springcloud
+type: Opaque
+data:
+  "API_TOKEN": "YWJj"
+  "DATASOURCE_PASSWORD": "aHVudGVyMg=="
+`
+
+var testProps = map[string]string{
+	"datasource.url":      "jdbc:mysql://db/app",
+	"datasource.password": "hunter2",
+	"api.token":           "{cipher}cba",
+	"servers[0].host":     "a",
+}
+
+func reverseDecrypt(cipher string) (string, error) {
+	r := []rune(cipher[len("{cipher}"):])
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r), nil
+}
+
+func TestRender(t *testing.T) {
+	opts := Options{Name: "myapp", Namespace: "prod"}
+	data, secrets, err := Split(testProps, reverseDecrypt, opts)
+	assert.NoError(t, err)
+
+	b := &bytes.Buffer{}
+	assert.NoError(t, Render(b, data, secrets, opts))
+	assert.Equal(t, expectedManifests, b.String())
+}
+
+func TestSplitPropertyKeys(t *testing.T) {
+	data, secrets, err := Split(testProps, reverseDecrypt, Options{KeyFormat: KeyProperty})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"datasource.url": "jdbc:mysql://db/app", "servers.0.host": "a"}, data)
+	assert.Equal(t, map[string]string{"datasource.password": "hunter2", "api.token": "abc"}, secrets)
+
+	_, _, err = Split(testProps, func(string) (string, error) { return "", errors.New("no key") }, Options{})
+	assert.Error(t, err)
+}
+
+func TestSplitSecretKeys(t *testing.T) {
+	data, secrets, err := Split(map[string]string{"datasource.url": "jdbc:mysql://db/app", "api.dsn": "decrypted"},
+		reverseDecrypt, Options{SecretKeys: []string{"api.dsn"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"DATASOURCE_URL": "jdbc:mysql://db/app"}, data)
+	assert.Equal(t, map[string]string{"API_DSN": "decrypted"}, secrets)
+}
+
+func TestSplitErrors(t *testing.T) {
+	_, _, err := Split(map[string]string{"invalid.api.token": "<n/a>"}, reverseDecrypt, Options{})
+	assert.EqualError(t, err, "The config server could not decrypt api.token")
+
+	_, _, err = Split(map[string]string{"a.b": "1", "a_b": "2"}, reverseDecrypt, Options{})
+	assert.Equal(t, &KeyCollisionError{Key: "A_B", Properties: []string{"a.b", "a_b"}}, err)
+
+	_, _, err = Split(map[string]string{"servers[0].host": "a", "servers.0.host": "b"}, reverseDecrypt, Options{KeyFormat: KeyProperty})
+	assert.IsType(t, &KeyCollisionError{}, err)
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity ranks a lint finding
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// Lint rules
+const (
+	RuleUnresolvedPlaceholder = "unresolved-placeholder"
+	RuleUndecryptableCipher   = "undecryptable-cipher"
+	RuleRelaxedDuplicate      = "relaxed-duplicate"
+	RuleEmptyRequired         = "empty-required"
+	RuleProfileOverride       = "profile-override"
+)
+
+var (
+	placeholderRegex = regexp.MustCompile(`\$\{[^}]*\}`)
+
+	// requiredSuffixes are the final key segments which must not be empty
+	requiredSuffixes = []string{"url", "uri", "host", "hostname", "port", "username", "user", "password", "endpoint", "address"}
+
+	severityNames = []string{"info", "warning", "error"}
+)
+
+// Finding is a single problem reported by Lint
+type Finding struct {
+	Key      string   `json:"key"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (s Severity) String() string {
+	if int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalText encodes the severity by name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity converts a severity name (info, warning, error) into a Severity
+func ParseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if strings.EqualFold(n, name) {
+			return Severity(i), nil
+		}
+	}
+	return SeverityInfo, fmt.Errorf("Unknown severity %q", name)
+}
+
+// Lint fetches the configuration of client and reports unresolved placeholders,
+// {cipher} values which cannot be decrypted, keys duplicated by relaxed binding,
+// empty values for keys which look required and keys overridden by a higher
+// precedence property source.  Findings are sorted by key and rule
+func Lint(client ConfigClient) ([]*Finding, error) {
+	props, err := client.FetchAsMap()
+	if err != nil {
+		return nil, err
+	}
+	env, err := client.FetchEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	findings := lintProperties(props, os.LookupEnv, func(cipher string) error {
+		_, err := client.Decrypt(cipher)
+		return err
+	})
+	findings = append(findings, lintOverrides(env)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Key != findings[j].Key {
+			return findings[i].Key < findings[j].Key
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+	return findings, nil
+}
+
+// lintProperties checks the resolved properties.  lookupEnv resolves placeholders
+// which are not properties and decrypt is used to verify any value still holding a
+// {cipher} prefix
+func lintProperties(props map[string]string, lookupEnv func(string) (string, bool), decrypt func(string) error) []*Finding {
+	findings := []*Finding{}
+	add := func(key, rule string, severity Severity, format string, args ...interface{}) {
+		findings = append(findings, &Finding{Key: key, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	relaxed := map[string][]string{}
+	for _, k := range Properties(props).Keys() {
+		v := props[k]
+		if key, ok := Undecryptable(k, v); ok {
+			add(key, RuleUndecryptableCipher, SeverityError, "the config server could not decrypt the value")
+			continue
+		}
+		switch {
+		case IsCipher(v):
+			if err := decrypt(v); err != nil {
+				add(k, RuleUndecryptableCipher, SeverityError, "value cannot be decrypted: %s", err.Error())
+			}
+		case v == "" && isRequiredKey(k):
+			add(k, RuleEmptyRequired, SeverityWarning, "value is empty but the key looks required")
+		}
+
+		for _, p := range placeholderRegex.FindAllString(v, -1) {
+			if !resolvable(p, props, lookupEnv) {
+				add(k, RuleUnresolvedPlaceholder, SeverityError, "placeholder %s is not resolved", p)
+			}
+		}
+
+		canonical := relaxedKey(k)
+		relaxed[canonical] = append(relaxed[canonical], k)
+	}
+
+	for _, keys := range relaxed {
+		if len(keys) > 1 {
+			add(keys[0], RuleRelaxedDuplicate, SeverityWarning,
+				"keys %s only differ by relaxed binding form and bind to the same property", strings.Join(keys, ", "))
+		}
+	}
+	return findings
+}
+
+// lintOverrides reports keys declared by more than one property source.  Sources
+// are in order of precedence so the first declaration wins
+func lintOverrides(env *Environment) []*Finding {
+	findings := []*Finding{}
+	winners := map[string]string{}
+	for _, ps := range env.PropertySources {
+		keys := Properties{}
+		for k := range ps.Source {
+			keys[k] = ""
+		}
+		for _, k := range keys.Keys() {
+			if winner, ok := winners[k]; ok {
+				findings = append(findings, &Finding{
+					Key:      k,
+					Rule:     RuleProfileOverride,
+					Severity: SeverityInfo,
+					Message:  fmt.Sprintf("value from %s is overridden by %s", ps.Name, winner),
+				})
+				continue
+			}
+			winners[k] = ps.Name
+		}
+	}
+	return findings
+}
+
+// resolvable returns true if placeholder (ex. ${DB_HOST} or ${db.port:3306}) has a
+// default or references a property or environment variable.  As with Spring a
+// property may also be provided by the relaxed form of its name (see EnvName)
+func resolvable(placeholder string, props map[string]string, lookupEnv func(string) (string, bool)) bool {
+	name := strings.TrimSuffix(strings.TrimPrefix(placeholder, "${"), "}")
+	if strings.Contains(name, ":") {
+		return true
+	}
+	if _, ok := props[name]; ok {
+		return true
+	}
+	if _, ok := lookupEnv(name); ok {
+		return true
+	}
+	_, ok := lookupEnv(EnvName(name))
+	return ok
+}
+
+// relaxedKey returns the canonical form of key used by Spring relaxed binding
+func relaxedKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+// isRequiredKey returns true if the final segment of key is, or ends with, one of
+// requiredSuffixes (ex. url, jdbc-url, jdbcUrl)
+func isRequiredKey(key string) bool {
+	last := key[strings.LastIndexAny(key, ".]")+1:]
+	lower := strings.ToLower(last)
+	for _, s := range requiredSuffixes {
+		camel := strings.ToUpper(s[:1]) + s[1:]
+		if lower == s || strings.HasSuffix(lower, "-"+s) || strings.HasSuffix(lower, "_"+s) || strings.HasSuffix(last, camel) {
+			return true
+		}
+	}
+	return false
+}
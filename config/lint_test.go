@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLintProperties(t *testing.T) {
+	props := map[string]string{
+		"datasource.url":         "jdbc:mysql://${DB_HOST}/app",
+		"datasource.pool":        "${pool.size}:${DB_POOL:10}:${datasource.timeout}",
+		"datasource.password":    "{cipher}bad",
+		"datasource.username":    "{cipher}good",
+		"invalid.api.key":        "<n/a>",
+		"cache.host":             "",
+		"pool.size":              "5",
+		"support":                "",
+		"server.max-connections": "10",
+		"server.maxConnections":  "20",
+		"server.max_connections": "30",
+		"feature.description":    "",
+	}
+	env := map[string]string{"DATASOURCE_TIMEOUT": "30s"}
+	lookupEnv := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	decrypt := func(cipher string) error {
+		if cipher == "{cipher}bad" {
+			return errors.New("invalid")
+		}
+		return nil
+	}
+
+	assert.Equal(t, []*Finding{
+		{Key: "cache.host", Rule: RuleEmptyRequired, Severity: SeverityWarning,
+			Message: "value is empty but the key looks required"},
+		{Key: "datasource.password", Rule: RuleUndecryptableCipher, Severity: SeverityError,
+			Message: "value cannot be decrypted: invalid"},
+		{Key: "datasource.url", Rule: RuleUnresolvedPlaceholder, Severity: SeverityError,
+			Message: "placeholder ${DB_HOST} is not resolved"},
+		{Key: "api.key", Rule: RuleUndecryptableCipher, Severity: SeverityError,
+			Message: "the config server could not decrypt the value"},
+		{Key: "server.max-connections", Rule: RuleRelaxedDuplicate, Severity: SeverityWarning,
+			Message: "keys server.max-connections, server.maxConnections, server.max_connections only differ by relaxed binding form and bind to the same property"},
+	}, lintProperties(props, lookupEnv, decrypt))
+}
+
+func TestLint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "."+extPROP) {
+			w.Write([]byte("datasource.url:  jdbc:prod\ndatasource.user:  app\npool.size:  10\n"))
+			return
+		}
+		w.Write([]byte(environmentJSON))
+	}))
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	findings, err := Lint(cfg)
+	assert.NoError(t, err)
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, &Finding{
+			Key:      "datasource.url",
+			Rule:     RuleProfileOverride,
+			Severity: SeverityInfo,
+			Message:  "value from git:https://github.com/acme/config/application.yml is overridden by git:https://github.com/acme/config/myapp-prod.yml",
+		}, findings[0])
+	}
+}
+
+func TestIsRequiredKey(t *testing.T) {
+	assert.True(t, isRequiredKey("spring.datasource.jdbc-url"))
+	assert.True(t, isRequiredKey("spring.datasource.jdbcUrl"))
+	assert.True(t, isRequiredKey("servers[0].host"))
+	assert.False(t, isRequiredKey("support"))
+	assert.False(t, isRequiredKey("cache.ttl"))
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"github.com/ContainX/go-springcloud/discovery/eureka"
+	"github.com/ContainX/go-springcloud/discovery/eureka/model"
+	"github.com/op/go-logging"
+	"strings"
+	"sync"
+)
+
+const (
+	// LoggingLevelPrefix is the prefix of properties setting logger levels, as in
+	// Spring (ex. logging.level.discovery=DEBUG)
+	LoggingLevelPrefix = "logging.level."
+
+	// RootLogger is the logger name whose level applies to loggers without a level
+	RootLogger = "root"
+
+	// levelOff is below CRITICAL, the most severe go-logging level, so no message
+	// is enabled
+	levelOff = logging.CRITICAL - 1
+)
+
+// springLevels maps Spring log levels onto go-logging levels.  Levels not listed
+// are parsed by go-logging (ex. NOTICE, CRITICAL)
+var springLevels = map[string]logging.Level{
+	"TRACE": logging.DEBUG,
+	"WARN":  logging.WARNING,
+	"FATAL": logging.CRITICAL,
+	"OFF":   levelOff,
+}
+
+var (
+	loggersMu sync.Mutex
+	// loggers are the names whose levels are managed, including those set by
+	// previously applied properties so their levels revert once removed.  Packages
+	// importing config register their own loggers
+	loggers = map[string]bool{
+		LoggerName:        true,
+		eureka.LoggerName: true,
+		model.LoggerName:  true,
+	}
+	// originalLevels holds the level of each overridden logger before its level was
+	// set from the properties
+	originalLevels = map[string]logging.Level{}
+	// appliedLevels are the levels of the last applied properties
+	appliedLevels = map[string]logging.Level{}
+)
+
+// RegisterLoggers adds application loggers whose levels are set from logging.level.*
+// properties.  As in Spring a level applies to the named logger and the loggers
+// beneath it (logging.level.discovery applies to discovery.model) unless they have
+// a level of their own
+func RegisterLoggers(names ...string) {
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	for _, name := range names {
+		loggers[name] = true
+	}
+	applyLevels()
+}
+
+// ApplyLogLevels sets the level of the managed loggers from the logging.level.*
+// properties in p.  Loggers no longer matching a property are restored to the
+// level they had before.  It is called on every refresh unless Bootstrap.DisableLogLevels
+// is set.  OFF disables every message of a logger.
+//
+// Loggers from go-utils/logger are go-logging loggers whose levels are held in the
+// process-wide go-logging registry.  When several clients apply logging.level.*
+// properties the last refresh wins, so DisableLogLevels should be set on all but one
+func ApplyLogLevels(p Properties) {
+	levels := map[string]logging.Level{}
+	for k, v := range p {
+		if !strings.HasPrefix(k, LoggingLevelPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(k, LoggingLevelPrefix)
+		level, err := parseLevel(v)
+		if err != nil {
+			log.Warningf("Ignoring invalid level %q for logger %s", v, name)
+			continue
+		}
+		levels[name] = level
+	}
+
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	for name := range levels {
+		loggers[name] = true
+	}
+	appliedLevels = levels
+	applyLevels()
+}
+
+// applyLevels sets every managed logger to the level of its closest configured
+// ancestor.  Callers must hold loggersMu
+func applyLevels() {
+	for name := range loggers {
+		if name == RootLogger {
+			continue
+		}
+		level, ok := levelFor(name)
+		original, overridden := originalLevels[name]
+		switch {
+		case ok:
+			if !overridden {
+				originalLevels[name] = logging.GetLevel(name)
+			}
+			logging.SetLevel(level, name)
+		case overridden:
+			logging.SetLevel(original, name)
+			delete(originalLevels, name)
+		}
+	}
+}
+
+// levelFor returns the level of name or, failing that, of the nearest parent
+// logger or the root logger
+func levelFor(name string) (logging.Level, bool) {
+	for n := name; n != ""; {
+		if level, ok := appliedLevels[n]; ok {
+			return level, true
+		}
+		i := strings.LastIndex(n, ".")
+		if i < 0 {
+			break
+		}
+		n = n[:i]
+	}
+	level, ok := appliedLevels[RootLogger]
+	return level, ok
+}
+
+func parseLevel(s string) (logging.Level, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if level, ok := springLevels[s]; ok {
+		return level, nil
+	}
+	return logging.LogLevel(s)
+}
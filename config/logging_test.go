@@ -0,0 +1,65 @@
+package config
+
+import (
+	"github.com/ContainX/go-utils/logger"
+	"github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApplyLogLevels(t *testing.T) {
+	logging.SetLevel(logging.INFO, "discovery")
+	logging.SetLevel(logging.INFO, "discovery.model")
+	RegisterLoggers("myapp.db")
+	logging.SetLevel(logging.INFO, "myapp.db")
+
+	ApplyLogLevels(Properties{
+		"logging.level.discovery": "DEBUG",
+		"logging.level.myapp":     "warn",
+		"logging.level.other":     "bogus",
+	})
+	assert.Equal(t, logging.DEBUG, logging.GetLevel("discovery"))
+	assert.Equal(t, logging.DEBUG, logging.GetLevel("discovery.model"))
+	assert.Equal(t, logging.WARNING, logging.GetLevel("myapp.db"))
+
+	ApplyLogLevels(Properties{
+		"logging.level.root":            "ERROR",
+		"logging.level.discovery.model": "TRACE",
+	})
+	assert.Equal(t, logging.ERROR, logging.GetLevel("discovery"))
+	assert.Equal(t, logging.DEBUG, logging.GetLevel("discovery.model"))
+	assert.Equal(t, logging.ERROR, logging.GetLevel("myapp.db"))
+
+	// levels are restored once the properties are removed
+	ApplyLogLevels(Properties{})
+	assert.Equal(t, logging.INFO, logging.GetLevel("discovery"))
+	assert.Equal(t, logging.INFO, logging.GetLevel("discovery.model"))
+	assert.Equal(t, logging.INFO, logging.GetLevel("myapp.db"))
+}
+
+func TestApplyLogLevelsOff(t *testing.T) {
+	logging.SetLevel(logging.INFO, "discovery")
+	l := logger.GetLogger("discovery")
+
+	ApplyLogLevels(Properties{"logging.level.discovery": "OFF"})
+	assert.False(t, l.IsEnabledFor(logging.CRITICAL))
+
+	ApplyLogLevels(Properties{"logging.level.discovery": "WARN"})
+	assert.True(t, l.IsEnabledFor(logging.WARNING))
+	assert.False(t, l.IsEnabledFor(logging.INFO))
+
+	ApplyLogLevels(Properties{})
+	assert.True(t, l.IsEnabledFor(logging.INFO))
+}
+
+func TestRefreshAppliesLogLevels(t *testing.T) {
+	server := startChangingServer("logging.level.config.template:  ERROR\n", "foo:  bar\n")
+	defer server.Close()
+	logging.SetLevel(logging.INFO, "config.template")
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	cfg.Refresh()
+	assert.Equal(t, logging.ERROR, logging.GetLevel("config.template"))
+	cfg.Refresh()
+	assert.Equal(t, logging.INFO, logging.GetLevel("config.template"))
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryLeeway renews access tokens shortly before they expire
+const tokenExpiryLeeway = 30 * time.Second
+
+// OAuth2 holds client credentials exchanged for a bearer token when contacting the
+// configuration server, as required by Spring Cloud Services.  When declared they
+// are used instead of HTTP Basic credentials, including those of servers located
+// through Discovery
+type OAuth2 struct {
+	ClientId       string `json:"clientId"`
+	ClientSecret   string `json:"clientSecret,omitempty"`
+	AccessTokenUri string `json:"accessTokenUri"`
+}
+
+// tokens caches the access token of each set of client credentials so the clients
+// of a Batch share them.  Keys hold a hash of the secret rather than the secret so
+// credentials differing only by secret are not given each other's tokens
+var tokens sync.Map
+
+// tokenSource fetches and caches the access token for a set of client credentials
+type tokenSource struct {
+	creds OAuth2
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// tokenResponse is the body returned by the token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// newTokenSource returns the shared token source for o or nil when no client
+// credentials are declared
+func newTokenSource(o *OAuth2) *tokenSource {
+	if o == nil || o.ClientId == "" {
+		return nil
+	}
+	secret := sha256.Sum256([]byte(o.ClientSecret))
+	key := o.AccessTokenUri + "|" + o.ClientId + "|" + hex.EncodeToString(secret[:])
+	ts, _ := tokens.LoadOrStore(key, &tokenSource{creds: *o})
+	return ts.(*tokenSource)
+}
+
+// Token returns a valid access token using the client credentials grant
+func (t *tokenSource) Token() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Now().Before(t.expires) {
+		return t.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest(http.MethodPost, t.creds.AccessTokenUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(headerContent, "application/x-www-form-urlencoded")
+	req.Header.Set(headerAccept, "application/json")
+	req.SetBasicAuth(url.QueryEscape(t.creds.ClientId), url.QueryEscape(t.creds.ClientSecret))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("Token endpoint %s returned %d", redactURI(t.creds.AccessTokenUri), resp.StatusCode)
+	}
+
+	tr := &tokenResponse{}
+	if err := json.Unmarshal(content, tr); err != nil {
+		return "", err
+	}
+	if tr.AccessToken == "" {
+		return "", fmt.Errorf("Token endpoint %s returned no access token", redactURI(t.creds.AccessTokenUri))
+	}
+
+	t.token = tr.AccessToken
+	t.expires = time.Now().Add(time.Duration(tr.ExpiresIn)*time.Second - tokenExpiryLeeway)
+	return t.token, nil
+}
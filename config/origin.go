@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Origins of properties which do not come from the configuration server.  Names
+// follow the Spring property source names
+const (
+	OriginCommandLine = "commandLineArgs"
+	OriginEnvironment = "systemEnvironment"
+	OriginDefault     = "defaultProperties"
+)
+
+// LocalSources are the property sources of the running application which are
+// layered beneath the remote configuration when resolving origins
+type LocalSources struct {
+	// Args are command line arguments.  Spring style --key=value arguments are used.
+	Args []string
+
+	// Environ is the environment in KEY=value form (default os.Environ()).  A variable
+	// provides a property when its name is the relaxed form of the key (see EnvName).
+	Environ []string
+
+	// Defaults are the lowest precedence properties.
+	Defaults map[string]string
+}
+
+// PropertyValue is a value along with the property source it came from
+type PropertyValue struct {
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// OriginProperty is a resolved property.  Value and Origin hold the winning value
+// while Shadowed lists the values it overrides in order of precedence
+type OriginProperty struct {
+	Key string `json:"key"`
+	PropertyValue
+	Shadowed []PropertyValue `json:"shadowed,omitempty"`
+}
+
+func (c *client) FetchWithOrigins(local LocalSources) (map[string]*OriginProperty, error) {
+	env, err := c.FetchEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	trees, err := c.configTreeSources()
+	if err != nil {
+		return nil, err
+	}
+	env.PropertySources = append(trees, env.PropertySources...)
+	return ResolveOrigins(env, local), nil
+}
+
+// ResolveOrigins resolves every property of env and local along with its origin.
+// As with the Spring config client the remote property sources take precedence
+// over local ones, followed by command line arguments, environment variables and
+// finally defaults
+func ResolveOrigins(env *Environment, local LocalSources) map[string]*OriginProperty {
+	remote := []layer{}
+	if env != nil {
+		for _, ps := range env.PropertySources {
+			l := layer{origin: ps.Name, values: Properties{}}
+			for k, v := range ps.Source {
+				l.values[k] = formatScalar(v)
+			}
+			remote = append(remote, l)
+		}
+	}
+	args := layer{origin: OriginCommandLine, values: parseArgs(local.Args)}
+	defaults := layer{origin: OriginDefault, values: Properties(local.Defaults)}
+
+	// environment variables can only be matched against keys declared by another
+	// source since relaxed names cannot be reversed
+	environ := local.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	vars := map[string]string{}
+	for _, kv := range environ {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			vars[kv[:i]] = kv[i+1:]
+		}
+	}
+	envVars := layer{origin: OriginEnvironment, values: Properties{}, named: true}
+	for _, l := range append(append(remote, args), defaults) {
+		for k := range l.values {
+			if v, ok := vars[EnvName(k)]; ok {
+				envVars.values[k] = v
+			}
+		}
+	}
+
+	resolved := map[string]*OriginProperty{}
+	for _, l := range append(remote, args, envVars, defaults) {
+		for _, k := range l.values.Keys() {
+			pv := PropertyValue{Value: l.values[k], Origin: l.origin}
+			if l.named {
+				pv.Origin = l.origin + ":" + EnvName(k)
+			}
+			if p, ok := resolved[k]; ok {
+				p.Shadowed = append(p.Shadowed, pv)
+			} else {
+				resolved[k] = &OriginProperty{Key: k, PropertyValue: pv}
+			}
+		}
+	}
+	return resolved
+}
+
+// layer is a property source considered by ResolveOrigins
+type layer struct {
+	origin string
+	values Properties
+	// named appends the environment variable name to the origin
+	named bool
+}
+
+// parseArgs returns the --key=value command line arguments as properties
+func parseArgs(args []string) Properties {
+	p := Properties{}
+	for _, a := range args {
+		if !strings.HasPrefix(a, "--") {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(a, "--"), "=", 2)
+		if len(kv) == 2 && kv[0] != "" {
+			p[kv[0]] = kv[1]
+		}
+	}
+	return p
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const environmentJSON = `{
+  "name": "myapp",
+  "profiles": ["prod"],
+  "label": "master",
+  "version": "abc123",
+  "propertySources": [
+    {"name": "git:https://github.com/acme/config/myapp-prod.yml", "source": {"datasource.url": "jdbc:prod", "pool.size": 10}},
+    {"name": "git:https://github.com/acme/config/application.yml", "source": {"datasource.url": "jdbc:dev", "datasource.user": "app"}}
+  ]
+}`
+
+func TestFetchWithOrigins(t *testing.T) {
+	server := startServer(environmentJSON)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL, Profile: "prod"})
+	props, err := cfg.FetchWithOrigins(LocalSources{
+		Args:     []string{"--datasource.url=jdbc:cli", "--timeout=5", "positional"},
+		Environ:  []string{"DATASOURCE_URL=jdbc:env", "TIMEOUT=10", "HOME=/root"},
+		Defaults: map[string]string{"timeout": "30"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, props, 4)
+
+	url := props["datasource.url"]
+	assert.Equal(t, PropertyValue{Value: "jdbc:prod", Origin: "git:https://github.com/acme/config/myapp-prod.yml"}, url.PropertyValue)
+	assert.Equal(t, []PropertyValue{
+		{Value: "jdbc:dev", Origin: "git:https://github.com/acme/config/application.yml"},
+		{Value: "jdbc:cli", Origin: OriginCommandLine},
+		{Value: "jdbc:env", Origin: "systemEnvironment:DATASOURCE_URL"},
+	}, url.Shadowed)
+
+	timeout := props["timeout"]
+	assert.Equal(t, "5", timeout.Value)
+	assert.Equal(t, []PropertyValue{
+		{Value: "10", Origin: "systemEnvironment:TIMEOUT"},
+		{Value: "30", Origin: OriginDefault},
+	}, timeout.Shadowed)
+
+	assert.Equal(t, "10", props["pool.size"].Value)
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"reflect"
+	"runtime/debug"
+	"time"
+)
+
+// RefreshFunc is notified after a refresh has changed the configuration.  The old
+// properties are nil on the first refresh
+type RefreshFunc func(old, new Properties)
+
+// Refresh fetches the remote configuration, validating it when a schema is declared,
+// records it in the history and, if the properties differ from those currently
+// applied, applies the logging.level.* properties and notifies the listeners
+// registered with OnRefresh in the order they were added.  While pinned the
+// configuration is recorded but not applied.  The returned bool indicates a change
+func (c *client) Refresh() (bool, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	var m Properties
+	var err error
+	if c.schema != nil {
+		m, err = c.fetchValidated()
+		if _, ok := err.(*ValidationError); ok {
+			log.Warning("Refusing to apply invalid configuration, keeping the last good one")
+		}
+	} else {
+		m, err = c.FetchAsMap()
+	}
+	if err != nil {
+		return false, err
+	}
+	c.record(version(m), m)
+
+	if c.Pinned() != "" {
+		return false, nil
+	}
+	return c.apply(m), nil
+}
+
+// apply makes p the current properties and notifies the listeners if they differ
+// from the previous properties.  Callers must hold refreshMu
+func (c *client) apply(p Properties) bool {
+	c.mu.Lock()
+	old := c.current
+	if old != nil && reflect.DeepEqual(old, p) {
+		c.mu.Unlock()
+		return false
+	}
+	c.current = p
+	listeners := append([]RefreshFunc{}, c.listeners...)
+	changeListeners := append([]*changeListener{}, c.changeListeners...)
+	c.mu.Unlock()
+
+	if !c.bootstrap.DisableLogLevels {
+		ApplyLogLevels(p)
+	}
+	for _, fn := range listeners {
+		notifyRefresh(fn, old, p)
+	}
+	c.notifyChanges(old, p, changeListeners)
+	return true
+}
+
+// notifyRefresh calls fn recovering and logging any panic so the remaining
+// listeners are still notified
+func notifyRefresh(fn RefreshFunc, old, new Properties) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Refresh listener panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+	fn(old, new)
+}
+
+func (c *client) OnRefresh(fn RefreshFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+func (c *client) Properties() Properties {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.current == nil {
+		return nil
+	}
+	p := make(Properties, len(c.current))
+	for k, v := range c.current {
+		p[k] = v
+	}
+	return p
+}
+
+func (c *client) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.Refresh(); err != nil {
+			log.Errorf("Error refreshing configuration: %s", err.Error())
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
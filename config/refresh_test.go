@@ -0,0 +1,53 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// startChangingServer serves bodies in sequence for properties requests, repeating
+// the last one.  Other requests are not found
+func startChangingServer(bodies ...string) *httptest.Server {
+	var n int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "."+extPROP) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		i := int(atomic.AddInt32(&n, 1)) - 1
+		if i >= len(bodies) {
+			i = len(bodies) - 1
+		}
+		w.Write([]byte(bodies[i]))
+	}))
+}
+
+func TestRefresh(t *testing.T) {
+	server := startChangingServer("foo:  bar\n", "foo:  bar\n", "foo:  baz\n")
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	assert.Nil(t, cfg.Properties())
+
+	calls := []Properties{}
+	cfg.OnRefresh(func(old, new Properties) {
+		calls = append(calls, old, new)
+	})
+
+	for _, expected := range []bool{true, false, true} {
+		changed, err := cfg.Refresh()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, changed)
+	}
+
+	assert.Equal(t, []Properties{nil, {"foo": "bar"}, {"foo": "bar"}, {"foo": "baz"}}, calls)
+	assert.Equal(t, "baz", cfg.Properties()["foo"])
+
+	// callers receive a copy
+	cfg.Properties()["foo"] = "changed"
+	assert.Equal(t, "baz", cfg.Properties()["foo"])
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypeText     = "text/plain"
+	headerAccept        = "Accept"
+	headerContent       = "Content-Type"
+	headerAuthorization = "Authorization"
+)
+
+// maxIdleConnsPerHost allows concurrent fetches, such as a Batch, to reuse connections
+const maxIdleConnsPerHost = 32
+
+// httpClient is shared by all config clients so connections to the
+// configuration server are pooled
+var httpClient = &http.Client{Timeout: 30 * time.Second, Transport: newTransport()}
+
+func newTransport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return t
+}
+
+// ServerError is returned when the configuration server responds with a
+// non 2XX status code.  Status and Description are populated from the
+// Spring error body when one is present.
+type ServerError struct {
+	StatusCode  int    `json:"-"`
+	Status      string `json:"status"`
+	Description string `json:"description"`
+}
+
+func (e *ServerError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("config server returned %d (%s): %s", e.StatusCode, e.Status, e.Description)
+	}
+	return fmt.Sprintf("config server returned %d", e.StatusCode)
+}
+
+// endpoint is a resolved configuration server location and the credentials
+// used to contact it
+type endpoint struct {
+	uri      string
+	username string
+	password string
+	token    *tokenSource
+}
+
+// send performs a request for path against the configuration server.  When more
+// than one server is available (see Discovery) each one is tried in turn until one
+// responds without a connection failure or 5XX status.  The response body is
+// returned as a string
+func (c *client) send(method, path, contentType, body string) (string, error) {
+	endpoints, err := c.resolveEndpoints()
+	if err != nil {
+		return "", err
+	}
+
+	for i, ep := range endpoints {
+		content, err := ep.send(method, path, contentType, body)
+		if err == nil || !isFailover(err) || i == len(endpoints)-1 {
+			return content, err
+		}
+		log.Warningf("Config server %s failed (%s), trying next instance", redactURI(ep.uri), err.Error())
+	}
+	return "", NoServerAvailableErr
+}
+
+// resolveEndpoints returns the candidate configuration servers in order of preference.
+// The CONFIG_SERVER_URI environment variable takes precedence followed by Eureka
+// discovery (if enabled) and finally the bootstrap URI
+func (c *client) resolveEndpoints() ([]*endpoint, error) {
+	if os.Getenv(EnvConfigServerURI) == "" && c.bootstrap.Discovery.Enabled {
+		return c.discoverEndpoints()
+	}
+	return []*endpoint{{
+		uri:      c.resolveURI(),
+		username: c.bootstrap.Username,
+		password: c.bootstrap.Password,
+		token:    c.token,
+	}}, nil
+}
+
+// send performs the request against this endpoint applying an OAuth2 bearer token
+// or HTTP Basic credentials when they are declared
+func (e *endpoint) send(method, path, contentType, body string) (string, error) {
+	req, err := http.NewRequest(method, e.url(path), strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	if contentType != "" {
+		req.Header.Set(headerContent, contentType)
+	}
+	req.Header.Set(headerAccept, "*/*")
+	if e.token != nil {
+		token, err := e.token.Token()
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set(headerAuthorization, "Bearer "+token)
+	} else if e.username != "" {
+		req.SetBasicAuth(e.username, e.password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		se := &ServerError{StatusCode: resp.StatusCode}
+		json.Unmarshal(content, se)
+		return "", se
+	}
+	return string(content), nil
+}
+
+// url joins the endpoint URI with path
+func (e *endpoint) url(path string) string {
+	return strings.TrimSuffix(e.uri, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// isFailover returns true if err indicates the server could not handle the request
+// and another instance should be tried
+func isFailover(err error) bool {
+	if se, ok := err.(*ServerError); ok {
+		return se.StatusCode >= 500
+	}
+	return true
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ScopeClosedErr = errors.New("Refresh scope has been closed")
+)
+
+// RefreshScope rebuilds registered components when the configuration refreshes,
+// the equivalent of Spring's @RefreshScope.  Components are registered with
+// NewScoped and obtained with Scoped.Get; an instance replaced by a refresh is
+// closed once every user has released it.
+type RefreshScope struct {
+	client     ConfigClient
+	mu         sync.Mutex
+	components []refreshable
+	closed     bool
+}
+
+// refreshable is implemented by Scoped for each component type
+type refreshable interface {
+	affected(old, new Properties) bool
+	rebuild(p Properties)
+	shutdown()
+}
+
+// Scoped is a component of type T managed by a RefreshScope
+type Scoped[T any] struct {
+	name     string
+	factory  func(Properties) (T, error)
+	close    func(T) error
+	patterns [][]string
+
+	mu      sync.Mutex
+	current *generation[T]
+	closed  bool
+}
+
+// generation is a single instance of a component along with its active users
+type generation[T any] struct {
+	value   T
+	refs    int
+	retired bool
+	closed  bool
+}
+
+// NewRefreshScope creates a scope rebuilding components after each refresh of client
+// which changed the configuration
+func NewRefreshScope(client ConfigClient) *RefreshScope {
+	s := &RefreshScope{client: client}
+	client.OnRefresh(s.refresh)
+	return s
+}
+
+// NewScoped registers a component built by factory within scope and creates its first
+// instance from the current properties (refreshing the client if it has not been
+// already).  close releases a replaced instance and may be nil.  When keys are
+// declared the component is only rebuilt when a property matching one of the key
+// patterns (see OnChange) changes, otherwise on every configuration change.
+// The first instance is built while holding the scope, so factory must not register
+// components itself.  ScopeClosedErr is returned if the scope has been closed
+func NewScoped[T any](scope *RefreshScope, name string, factory func(Properties) (T, error), close func(T) error, keys ...string) (*Scoped[T], error) {
+	if scope.client.Properties() == nil {
+		if _, err := scope.client.Refresh(); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Scoped[T]{name: name, factory: factory, close: close}
+	for _, k := range keys {
+		s.patterns = append(s.patterns, keySegments(k))
+	}
+
+	// building and registering under the scope lock ensures a refresh applied while
+	// the factory runs rebuilds the component instead of missing it
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if scope.closed {
+		return nil, ScopeClosedErr
+	}
+	value, err := factory(scope.client.Properties())
+	if err != nil {
+		return nil, err
+	}
+	s.current = &generation[T]{value: value}
+	scope.components = append(scope.components, s)
+	return s, nil
+}
+
+// Close closes the current instance of every component.  Instances still in use
+// are closed when released and Get returns ScopeClosedErr afterwards
+func (s *RefreshScope) Close() {
+	s.mu.Lock()
+	components := s.components
+	s.components = nil
+	s.closed = true
+	s.mu.Unlock()
+
+	for _, c := range components {
+		c.shutdown()
+	}
+}
+
+func (s *RefreshScope) refresh(old, new Properties) {
+	if old == nil {
+		return
+	}
+
+	// held while rebuilding so components registered concurrently are either built
+	// from new or rebuilt here
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.components {
+		if c.affected(old, new) {
+			c.rebuild(new)
+		}
+	}
+}
+
+// Get returns the current instance and a release function which must be called when
+// the caller has finished with it.  The instance is not closed before it is released.
+// ScopeClosedErr is returned once the scope has been closed
+func (s *Scoped[T]) Get() (T, func(), error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		var zero T
+		return zero, func() {}, ScopeClosedErr
+	}
+	g := s.current
+	g.refs++
+	s.mu.Unlock()
+
+	var once sync.Once
+	return g.value, func() {
+		once.Do(func() {
+			s.release(g)
+		})
+	}, nil
+}
+
+func (s *Scoped[T]) affected(old, new Properties) bool {
+	if len(s.patterns) == 0 {
+		return true
+	}
+	for _, k := range changedKeys(old, new) {
+		for _, p := range s.patterns {
+			if matchPattern(p, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rebuild creates a new instance from p and retires the current one.  If the
+// factory fails the current instance remains in use
+func (s *Scoped[T]) rebuild(p Properties) {
+	value, err := s.factory(p)
+	if err != nil {
+		log.Errorf("Error rebuilding %s, keeping the current instance: %s", s.name, err.Error())
+		return
+	}
+	log.Infof("Rebuilt %s from refreshed configuration", s.name)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		s.closeInstance(value)
+		return
+	}
+	old := s.current
+	s.current = &generation[T]{value: value}
+	s.mu.Unlock()
+
+	s.retire(old)
+}
+
+func (s *Scoped[T]) shutdown() {
+	s.mu.Lock()
+	g := s.current
+	s.closed = true
+	s.mu.Unlock()
+	s.retire(g)
+}
+
+// retire marks g as replaced and closes it if it has no users
+func (s *Scoped[T]) retire(g *generation[T]) {
+	s.mu.Lock()
+	g.retired = true
+	done := g.refs == 0 && !g.closed
+	g.closed = g.closed || done
+	s.mu.Unlock()
+
+	if done {
+		s.closeInstance(g.value)
+	}
+}
+
+// release drops a user of g, closing it if it has been retired and was the last user
+func (s *Scoped[T]) release(g *generation[T]) {
+	s.mu.Lock()
+	g.refs--
+	done := g.retired && g.refs == 0 && !g.closed
+	g.closed = g.closed || done
+	s.mu.Unlock()
+
+	if done {
+		s.closeInstance(g.value)
+	}
+}
+
+func (s *Scoped[T]) closeInstance(value T) {
+	if s.close == nil {
+		return
+	}
+	if err := s.close(value); err != nil {
+		log.Errorf("Error closing %s: %s", s.name, err.Error())
+	}
+}
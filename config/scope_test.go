@@ -0,0 +1,111 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testPool struct {
+	url    string
+	closed bool
+}
+
+func TestRefreshScope(t *testing.T) {
+	server := startChangingServer(
+		"datasource.url:  db1\nfoo:  bar\n",
+		"datasource.url:  db1\nfoo:  baz\n",
+		"datasource.url:  db2\nfoo:  baz\n",
+	)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	scope := NewRefreshScope(cfg)
+
+	pool, err := NewScoped(scope, "pool", func(p Properties) (*testPool, error) {
+		return &testPool{url: p["datasource.url"]}, nil
+	}, func(p *testPool) error {
+		p.closed = true
+		return nil
+	}, "datasource.**")
+	assert.NoError(t, err)
+
+	first, release, err := pool.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "db1", first.url)
+
+	// foo is not a dependency of the pool
+	cfg.Refresh()
+	p, r, _ := pool.Get()
+	assert.Equal(t, first, p)
+	r()
+
+	cfg.Refresh()
+	p, r, _ = pool.Get()
+	assert.Equal(t, "db2", p.url)
+	assert.False(t, first.closed, "in use instances must not be closed")
+
+	release()
+	release()
+	assert.True(t, first.closed)
+
+	scope.Close()
+	assert.False(t, p.closed)
+	r()
+	assert.True(t, p.closed)
+
+	closed, r, err := pool.Get()
+	assert.Equal(t, ScopeClosedErr, err)
+	assert.Nil(t, closed)
+	r()
+
+	_, err = NewScoped(scope, "other", func(p Properties) (*testPool, error) {
+		return &testPool{}, nil
+	}, nil)
+	assert.Equal(t, ScopeClosedErr, err)
+}
+
+func TestNewScopedDuringRefresh(t *testing.T) {
+	server := startChangingServer("datasource.url:  db1\n", "datasource.url:  db2\n")
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	cfg.Refresh()
+	scope := NewRefreshScope(cfg)
+
+	building := make(chan bool)
+	proceed := make(chan bool)
+	var pool *Scoped[*testPool]
+	var builds int32
+	registered := make(chan bool)
+	go func() {
+		pool, _ = NewScoped(scope, "pool", func(p Properties) (*testPool, error) {
+			if atomic.AddInt32(&builds, 1) == 1 {
+				building <- true
+				<-proceed
+			}
+			return &testPool{url: p["datasource.url"]}, nil
+		}, nil)
+		close(registered)
+	}()
+
+	// the refresh is applied while the first instance is built from db1
+	<-building
+	refreshed := make(chan bool)
+	go func() {
+		cfg.Refresh()
+		close(refreshed)
+	}()
+	for cfg.Properties()["datasource.url"] != "db2" {
+		time.Sleep(time.Millisecond)
+	}
+	close(proceed)
+	<-registered
+	<-refreshed
+
+	p, release, err := pool.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "db2", p.url)
+	release()
+}
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// LabelDefault is the label used when a request does not declare one
+	LabelDefault = "master"
+	// ApplicationDefault is the name of the files shared by every application
+	ApplicationDefault = "application"
+
+	placeholderApplication = "{application}"
+	placeholderProfile     = "{profile}"
+	placeholderLabel       = "{label}"
+
+	// globMeta are the path.Match metacharacters escaped within placeholder values
+	globMeta = `*?[\`
+
+	// labelSlash is how clients escape slashes within a label
+	labelSlash = "(_)"
+	// Format is git:{dir}/{file}
+	sourceNameFmt = "git:%s/%s"
+)
+
+// extensions are the supported configuration file types in order of precedence
+var extensions = []string{"properties", "yml", "yaml", "json"}
+
+var (
+	RepositoryNotDeclaredErr = errors.New("Git repository must be declared")
+	InvalidLabelErr          = errors.New("Label must not start with '-'")
+)
+
+// LabelNotFoundError is returned when a label is not a branch, tag or commit of
+// the repository
+type LabelNotFoundError struct {
+	Label string
+}
+
+func (e *LabelNotFoundError) Error() string {
+	return fmt.Sprintf("No such label: %s", e.Label)
+}
+
+// GitRepository reads configuration files from a local git repository, bare or
+// with a working tree.  Files are read from the commit a label (branch, tag or
+// commit id) refers to without checking it out, so requests for different labels
+// can be served concurrently.
+type GitRepository struct {
+	// Dir is the path to the repository.
+	Dir string
+
+	// SearchPaths are the directories, relative to the root of the repository, files
+	// are read from in order of precedence.  They may contain the {application},
+	// {profile} and {label} placeholders and path.Match patterns (ex. "{application}"
+	// or "services/*").  The root of the repository is always searched last.
+	SearchPaths []string
+
+	// DefaultLabel is used when a request does not declare a label (default master).
+	DefaultLabel string
+}
+
+// NewGitRepository creates a GitRepository for the repository at dir.  An error is
+// returned when dir is not a git repository
+func NewGitRepository(dir string, searchPaths ...string) (*GitRepository, error) {
+	if dir == "" {
+		return nil, RepositoryNotDeclaredErr
+	}
+	r := &GitRepository{Dir: dir, SearchPaths: searchPaths, DefaultLabel: LabelDefault}
+	if _, err := r.git("rev-parse", "--git-dir"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Find returns the property sources of application for the comma-separated profiles
+// at label.  The Environment version is the id of the commit label refers to
+func (r *GitRepository) Find(application, profile, label string) (*config.Environment, error) {
+	if label == "" {
+		label = r.DefaultLabel
+	}
+	if label == "" {
+		label = LabelDefault
+	}
+	label = strings.Replace(label, labelSlash, "/", -1)
+	if strings.HasPrefix(label, "-") {
+		return nil, InvalidLabelErr
+	}
+	profiles := splitProfiles(profile)
+
+	commit, err := r.resolve(label)
+	if err != nil {
+		return nil, err
+	}
+	files, err := r.files(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &config.Environment{
+		Name:            application,
+		Profiles:        profiles,
+		Label:           label,
+		Version:         commit,
+		PropertySources: []*config.PropertySource{},
+	}
+	for _, file := range r.candidates(application, profiles, label, files) {
+		content, err := r.git("cat-file", "blob", commit+":"+file)
+		if err != nil {
+			return nil, err
+		}
+		source, err := parseFile(file, content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err.Error())
+		}
+		env.PropertySources = append(env.PropertySources, &config.PropertySource{
+			Name:   fmt.Sprintf(sourceNameFmt, r.Dir, file),
+			Source: source,
+		})
+	}
+	return env, nil
+}
+
+// resolve returns the id of the commit label refers to
+func (r *GitRepository) resolve(label string) (string, error) {
+	out, err := r.git("rev-parse", "--verify", "--quiet", label+"^{commit}")
+	if err != nil {
+		return "", &LabelNotFoundError{Label: label}
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// files lists the paths of the files within commit
+func (r *GitRepository) files(commit string) (map[string]bool, error) {
+	out, err := r.git("ls-tree", "-r", "-z", "--name-only", commit)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]bool{}
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files[f] = true
+		}
+	}
+	return files, nil
+}
+
+// candidates returns the files among files holding the configuration of application,
+// in order of precedence (highest first).  Profile specific files take precedence,
+// with the last profile winning, and within each the application's files take
+// precedence over the shared application files
+func (r *GitRepository) candidates(application string, profiles []string, label string, files map[string]bool) []string {
+	dirs := r.searchDirs(application, profiles, label, files)
+	names := []string{application}
+	if application != ApplicationDefault {
+		names = append(names, ApplicationDefault)
+	}
+
+	bases := []string{}
+	for i := len(profiles) - 1; i >= 0; i-- {
+		for _, dir := range dirs {
+			for _, name := range names {
+				bases = append(bases, path.Join(dir, name+"-"+profiles[i]))
+			}
+		}
+	}
+	for _, dir := range dirs {
+		for _, name := range names {
+			bases = append(bases, path.Join(dir, name))
+		}
+	}
+
+	candidates := []string{}
+	seen := map[string]bool{}
+	for _, base := range bases {
+		for _, ext := range extensions {
+			file := base + "." + ext
+			if files[file] && !seen[file] {
+				seen[file] = true
+				candidates = append(candidates, file)
+			}
+		}
+	}
+	return candidates
+}
+
+// searchDirs resolves the search paths, followed by the root, into the directories
+// of files they match in order of precedence
+func (r *GitRepository) searchDirs(application string, profiles []string, label string, files map[string]bool) []string {
+	searchPaths := append(append([]string{}, r.SearchPaths...), "")
+
+	all := map[string]bool{}
+	for f := range files {
+		for dir := path.Dir(f); ; dir = path.Dir(dir) {
+			all[dir] = true
+			if dir == "." {
+				break
+			}
+		}
+	}
+	sorted := make([]string, 0, len(all))
+	for dir := range all {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+
+	dirs := []string{}
+	seen := map[string]bool{}
+	for _, sp := range searchPaths {
+		sp = strings.Replace(sp, placeholderApplication, escapeGlob(application), -1)
+		sp = strings.Replace(sp, placeholderLabel, escapeGlob(label), -1)
+		expanded := []string{sp}
+		if strings.Contains(sp, placeholderProfile) {
+			expanded = expanded[:0]
+			for _, p := range profiles {
+				expanded = append(expanded, strings.Replace(sp, placeholderProfile, escapeGlob(p), -1))
+			}
+		}
+		for _, pattern := range expanded {
+			pattern = path.Clean("/" + pattern)[1:]
+			if pattern == "" {
+				pattern = "."
+			}
+			for _, dir := range sorted {
+				if ok, _ := path.Match(pattern, dir); ok && !seen[dir] {
+					seen[dir] = true
+					dirs = append(dirs, dir)
+				}
+			}
+		}
+	}
+	return dirs
+}
+
+// escapeGlob escapes the path.Match metacharacters of a value taken from a request
+// so it only matches the directory it names
+func escapeGlob(s string) string {
+	b := &strings.Builder{}
+	for _, r := range s {
+		if strings.ContainsRune(globMeta, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// git runs a git command against the repository and returns its output
+func (r *GitRepository) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.Dir}, args...)...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], err.Error())
+	}
+	return out, nil
+}
+
+// splitProfiles splits the comma-separated profiles, defaulting to "default"
+func splitProfiles(profile string) []string {
+	profiles := []string{}
+	for _, p := range strings.Split(profile, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			profiles = append(profiles, p)
+		}
+	}
+	if len(profiles) == 0 {
+		profiles = append(profiles, config.ProfileDefault)
+	}
+	return profiles
+}
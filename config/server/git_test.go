@@ -0,0 +1,197 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo is a bare repository built by the tests from a working clone
+type testRepo struct {
+	t    *testing.T
+	bare string
+	work string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	r := &testRepo{t: t, bare: filepath.Join(dir, "config.git"), work: filepath.Join(dir, "work")}
+	r.git(dir, "init", "-q", "--bare", r.bare)
+	r.git(dir, "init", "-q", r.work)
+	return r
+}
+
+func (r *testRepo) git(dir string, args ...string) string {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commit writes files into the working clone, commits them and pushes the commit
+// to branch of the bare repository.  The commit id is returned
+func (r *testRepo) commit(branch string, files map[string]string) string {
+	for name, content := range files {
+		file := filepath.Join(r.work, name)
+		assert.NoError(r.t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.NoError(r.t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+	r.git(r.work, "add", "-A")
+	r.git(r.work, "commit", "-q", "-m", "update")
+	r.git(r.work, "push", "-q", r.bare, "HEAD:refs/heads/"+branch)
+	return r.git(r.work, "rev-parse", "HEAD")
+}
+
+func (r *testRepo) tag(name string) {
+	r.git(r.work, "tag", name)
+	r.git(r.work, "push", "-q", r.bare, "refs/tags/"+name)
+}
+
+func sourceNames(r *GitRepository, files ...string) []string {
+	names := []string{}
+	for _, f := range files {
+		names = append(names, "git:"+r.Dir+"/"+f)
+	}
+	return names
+}
+
+func TestGitRepositoryFind(t *testing.T) {
+	tr := newTestRepo(t)
+	v1 := tr.commit("master", map[string]string{
+		"application.yml":         "shared: root\nserver:\n  port: 8080\n",
+		"myapp/myapp.yml":         "greeting: v1\n",
+		"myapp/myapp-prod.yml":    "datasource:\n  url: jdbc:prod\n",
+		"myapp/application.yml":   "shared: myapp-dir\n",
+		"other/other.properties":  "greeting=other\n",
+		"application-prod.json":   `{"timeout": 1.5}`,
+		"myapp-dev.properties":    "# comment\ndebug: true\n",
+		"unrelated/myapp.yml":     "greeting: unrelated\n",
+		"application-staging.yml": "staging: true\n",
+	})
+	tr.tag("v1.0")
+	v2 := tr.commit("master", map[string]string{"myapp/myapp.yml": "greeting: v2\n"})
+
+	repo, err := NewGitRepository(tr.bare, "{application}")
+	assert.NoError(t, err)
+
+	env, err := repo.Find("myapp", "prod", "")
+	assert.NoError(t, err)
+	assert.Equal(t, v2, env.Version)
+	assert.Equal(t, "master", env.Label)
+	assert.Equal(t, []string{"prod"}, env.Profiles)
+
+	names := []string{}
+	for _, ps := range env.PropertySources {
+		names = append(names, ps.Name)
+	}
+	assert.Equal(t, sourceNames(repo, "myapp/myapp-prod.yml", "application-prod.json", "myapp/myapp.yml",
+		"myapp/application.yml", "application.yml"), names)
+
+	merged := Merge(env)
+	assert.Equal(t, "v2", merged["greeting"])
+	assert.Equal(t, "myapp-dir", merged["shared"])
+	assert.Equal(t, 8080, merged["server.port"])
+	assert.Equal(t, 1.5, merged["timeout"])
+	assert.Equal(t, "jdbc:prod", merged["datasource.url"])
+
+	// files are read from the tagged commit without checking it out
+	env, err = repo.Find("myapp", "prod", "v1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, v1, env.Version)
+	assert.Equal(t, "v1", Merge(env)["greeting"])
+
+	env, err = repo.Find("myapp", "default", v1)
+	assert.NoError(t, err)
+	assert.Equal(t, v1, env.Version)
+	assert.Equal(t, "v1", Merge(env)["greeting"])
+
+	// the last profile takes precedence
+	env, err = repo.Find("myapp", "dev,prod", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "jdbc:prod", Merge(env)["datasource.url"])
+	assert.Equal(t, "true", Merge(env)["debug"])
+	assert.Equal(t, sourceNames(repo, "myapp/myapp-prod.yml", "application-prod.json"),
+		[]string{env.PropertySources[0].Name, env.PropertySources[1].Name})
+
+	_, err = repo.Find("myapp", "prod", "missing")
+	assert.Equal(t, &LabelNotFoundError{Label: "missing"}, err)
+
+	_, err = repo.Find("myapp", "prod", "--output=/tmp/x")
+	assert.Equal(t, InvalidLabelErr, err)
+}
+
+func TestGitRepositorySearchPaths(t *testing.T) {
+	tr := newTestRepo(t)
+	tr.commit("master", map[string]string{
+		"services/myapp/myapp.yml":     "source: services\n",
+		"teams/a/myapp.yml":            "source: team-a\n",
+		"profiles/prod/myapp.yml":      "source: profile\n",
+		"labels/feature/x/myapp.yml":   "label: feature\n",
+		"myapp.yml":                    "source: root\nroot: true\n",
+		"services/other/other.yml":     "source: other\n",
+		"services/myapp/nested/a.yml":  "ignored: true\n",
+		"teams/b/application-prod.yml": "team: b\n",
+	})
+	tr.commit("feature/x", map[string]string{"labels/feature/x/myapp.yml": "label: feature-x\n"})
+
+	repo, err := NewGitRepository(tr.bare, "services/{application}", "profiles/{profile}", "teams/*", "labels/{label}")
+	assert.NoError(t, err)
+
+	env, err := repo.Find("myapp", "prod", "")
+	assert.NoError(t, err)
+	merged := Merge(env)
+	assert.Equal(t, "services", merged["source"])
+	assert.Equal(t, true, merged["root"])
+	assert.Equal(t, "b", merged["team"])
+	assert.Nil(t, merged["ignored"])
+	assert.Nil(t, merged["label"])
+
+	names := []string{}
+	for _, ps := range env.PropertySources {
+		names = append(names, ps.Name)
+	}
+	assert.Equal(t, sourceNames(repo, "teams/b/application-prod.yml", "services/myapp/myapp.yml",
+		"profiles/prod/myapp.yml", "teams/a/myapp.yml", "myapp.yml"), names)
+
+	// names from requests are not patterns
+	for _, name := range []string{"*", "[a-z]*", "myap?", "\\myapp"} {
+		env, err = repo.Find(name, "prod", "")
+		assert.NoError(t, err)
+		assert.Nil(t, Merge(env)["source"], name)
+	}
+	env, err = repo.Find("myapp", "*", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "services", Merge(env)["source"])
+	assert.Nil(t, Merge(env)["team"])
+
+	// slashes within labels are escaped by clients
+	env, err = repo.Find("myapp", "prod", "feature(_)x")
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/x", env.Label)
+	assert.Equal(t, "feature-x", Merge(env)["label"])
+}
+
+func TestNewGitRepository(t *testing.T) {
+	_, err := NewGitRepository("")
+	assert.Equal(t, RepositoryNotDeclaredErr, err)
+
+	// a directory outside of any repository
+	newTestRepo(t)
+	_, err = NewGitRepository(t.TempDir())
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/ContainX/go-springcloud/config"
+	"gopkg.in/yaml.v2"
+	"path"
+	"strings"
+)
+
+// parseFile parses a configuration file into flattened properties according to
+// its extension.  Values decoded from YAML and JSON keep their types
+func parseFile(file string, content []byte) (map[string]interface{}, error) {
+	tree := map[string]interface{}{}
+	switch path.Ext(file) {
+	case ".properties":
+		m := map[string]interface{}{}
+		for k, v := range parsePropertiesFile(string(content)) {
+			m[k] = v
+		}
+		return m, nil
+	case ".json":
+		if err := json.Unmarshal(content, &tree); err != nil {
+			return nil, err
+		}
+	default:
+		if err := yaml.Unmarshal(content, &tree); err != nil {
+			return nil, err
+		}
+	}
+	return config.FlattenValues(tree), nil
+}
+
+// parsePropertiesFile parses the "key=value" and "key: value" lines of a Java
+// properties file.  Lines starting with # or ! are comments
+func parsePropertiesFile(content string) map[string]string {
+	m := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			m[line] = ""
+			continue
+		}
+		m[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	return m
+}
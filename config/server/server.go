@@ -0,0 +1,169 @@
+// Package server is an embedded Spring Cloud Config server.  It serves the
+// environment ({name}/{profile}/{label}) and file ({label}/{name}-{profile}.yml,
+// .yaml, .properties or .json) endpoints used by config clients from a Repository,
+// such as a local git repository read with GitRepository.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/ContainX/go-utils/logger"
+	"gopkg.in/yaml.v2"
+	"net/http"
+	"strings"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeText = "text/plain"
+	contentTypeYAML = "text/yaml"
+)
+
+// LoggerName is the name of the logger used by the package
+const LoggerName = "config.server"
+
+var log = logger.GetLogger(LoggerName)
+
+func init() {
+	config.RegisterLoggers(LoggerName)
+}
+
+// Repository locates the property sources of an application
+type Repository interface {
+	// Find returns the property sources of application for the comma-separated
+	// profiles at label.  An empty label selects the repository default
+	Find(application, profile, label string) (*config.Environment, error)
+}
+
+// Server serves the config server HTTP endpoints from a Repository
+type Server struct {
+	repo Repository
+}
+
+// New creates a Server reading configuration from repo
+func New(repo Repository) *Server {
+	return &Server{repo: repo}
+}
+
+// errorBody is the Spring error response decoded by config.ServerError
+type errorBody struct {
+	Status      string `json:"status"`
+	Description string `json:"description"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	last := segments[len(segments)-1]
+	switch {
+	case len(segments) <= 2 && isFile(last):
+		label := ""
+		if len(segments) == 2 {
+			label = segments[0]
+		}
+		s.serveFile(w, label, last)
+	case len(segments) == 2 || len(segments) == 3:
+		label := ""
+		if len(segments) == 3 {
+			label = segments[2]
+		}
+		s.serveEnvironment(w, segments[0], segments[1], label)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// serveEnvironment writes the Environment of name and profile at label
+func (s *Server) serveEnvironment(w http.ResponseWriter, name, profile, label string) {
+	env, err := s.repo.Find(name, profile, label)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	json.NewEncoder(w).Encode(env)
+}
+
+// serveFile writes the merged properties of file, {name}-{profile}.{ext}, at label
+func (s *Server) serveFile(w http.ResponseWriter, label, file string) {
+	dot := strings.LastIndex(file, ".")
+	base, ext := file[:dot], file[dot+1:]
+	dash := strings.LastIndex(base, "-")
+	if dash <= 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	env, err := s.repo.Find(base[:dash], base[dash+1:], label)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	tree, err := config.UnflattenValues(Merge(env))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	switch ext {
+	case "properties":
+		w.Header().Set("Content-Type", contentTypeText)
+		props := config.Properties(config.Flatten(tree))
+		for _, k := range props.Keys() {
+			fmt.Fprintf(w, "%s: %s\n", k, props[k])
+		}
+	case "json":
+		w.Header().Set("Content-Type", contentTypeJSON)
+		json.NewEncoder(w).Encode(tree)
+	default:
+		b, err := yaml.Marshal(tree)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeYAML)
+		w.Write(b)
+	}
+}
+
+// Merge combines the property sources of env into a single set of properties where
+// sources of higher precedence override those of lower precedence
+func Merge(env *config.Environment) map[string]interface{} {
+	m := map[string]interface{}{}
+	for i := len(env.PropertySources) - 1; i >= 0; i-- {
+		for k, v := range env.PropertySources[i].Source {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// isFile reports whether segment names a configuration file
+func isFile(segment string) bool {
+	for _, ext := range extensions {
+		if strings.HasSuffix(segment, "."+ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeError writes err as a Spring error response
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if _, ok := err.(*LabelNotFoundError); ok {
+		status = http.StatusNotFound
+	} else if err == InvalidLabelErr {
+		status = http.StatusBadRequest
+	} else {
+		log.Errorf("Error serving configuration: %s", err.Error())
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&errorBody{Status: http.StatusText(status), Description: err.Error()})
+}
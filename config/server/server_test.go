@@ -0,0 +1,172 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServerEndpoints(t *testing.T) {
+	tr := newTestRepo(t)
+	v1 := tr.commit("master", map[string]string{
+		"application.yml":      "server:\n  port: 8080\nfeatures:\n  - a\n  - b\n",
+		"myapp/myapp.yml":      "greeting: hello\n",
+		"myapp/myapp-prod.yml": "greeting: hello prod\n",
+	})
+	tr.tag("v1")
+	v2 := tr.commit("master", map[string]string{"myapp/myapp.yml": "greeting: hello again\n"})
+
+	repo, err := NewGitRepository(tr.bare, "{application}")
+	assert.NoError(t, err)
+	server := httptest.NewServer(New(repo))
+	defer server.Close()
+
+	get := func(path string) (int, []byte) {
+		resp, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, b
+	}
+
+	status, body := get("/master/myapp-default.json")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"greeting": "hello again", "server": {"port": 8080}, "features": ["a", "b"]}`, string(body))
+
+	status, body = get("/master/myapp-default.properties")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "features[0]: a\nfeatures[1]: b\ngreeting: hello again\nserver.port: 8080\n", string(body))
+
+	status, body = get("/v1/myapp-prod.yml")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, string(body), "greeting: hello prod")
+
+	env := &config.Environment{}
+	status, body = get("/myapp/default")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NoError(t, json.Unmarshal(body, env))
+	assert.Equal(t, v2, env.Version)
+
+	status, body = get("/myapp/prod/v1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NoError(t, json.Unmarshal(body, env))
+	assert.Equal(t, v1, env.Version)
+	assert.Equal(t, "v1", env.Label)
+
+	status, body = get("/myapp/default/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.JSONEq(t, `{"status": "Not Found", "description": "No such label: missing"}`, string(body))
+}
+
+type appConfig struct {
+	Greeting string `json:"greeting"`
+	Server   struct {
+		Port int `json:"port"`
+	} `json:"server"`
+	Features []string `json:"features"`
+}
+
+func TestServerWithClient(t *testing.T) {
+	t.Setenv("CONFIG_PROFILE", "")
+	tr := newTestRepo(t)
+	v1 := tr.commit("master", map[string]string{
+		"application.yml":      "server:\n  port: 8080\nfeatures:\n  - a\n  - b\n",
+		"myapp/myapp.yml":      "greeting: hello\n",
+		"myapp/myapp-prod.yml": "greeting: hello prod\n",
+	})
+	tr.tag("v1")
+	v2 := tr.commit("master", map[string]string{"myapp/myapp.yml": "greeting: hello again\n"})
+
+	repo, err := NewGitRepository(tr.bare, "{application}")
+	assert.NoError(t, err)
+	server := httptest.NewServer(New(repo))
+	defer server.Close()
+
+	client, err := config.New(config.Bootstrap{URI: server.URL, Name: "myapp"})
+	assert.NoError(t, err)
+
+	cfg := &appConfig{}
+	assert.NoError(t, client.Fetch(cfg))
+	assert.Equal(t, "hello again", cfg.Greeting)
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, []string{"a", "b"}, cfg.Features)
+
+	m, err := client.FetchAsMap()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello again", m["greeting"])
+	assert.Equal(t, "8080", m["server.port"])
+	assert.Equal(t, "b", m["features[1]"])
+
+	yml, err := client.FetchAsYAML()
+	assert.NoError(t, err)
+	assert.Contains(t, yml, "greeting: hello again")
+
+	env, err := client.FetchEnvironment()
+	assert.NoError(t, err)
+	assert.Equal(t, v2, env.Version)
+
+	client, err = config.New(config.Bootstrap{URI: server.URL, Name: "myapp", Profile: "prod", Label: "v1"})
+	assert.NoError(t, err)
+	env, err = client.FetchEnvironment()
+	assert.NoError(t, err)
+	assert.Equal(t, v1, env.Version)
+	m, err = client.FetchAsMap()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello prod", m["greeting"])
+
+	client, err = config.New(config.Bootstrap{URI: server.URL, Name: "myapp", Label: "missing"})
+	assert.NoError(t, err)
+	_, err = client.FetchAsMap()
+	if assert.IsType(t, &config.ServerError{}, err) {
+		assert.Equal(t, http.StatusNotFound, err.(*config.ServerError).StatusCode)
+		assert.Equal(t, "No such label: missing", err.(*config.ServerError).Description)
+	}
+}
+
+func TestServerRoutes(t *testing.T) {
+	tr := newTestRepo(t)
+	tr.commit("master", map[string]string{"my-app.properties": "greeting=hi\n"})
+	repo, err := NewGitRepository(tr.bare)
+	assert.NoError(t, err)
+	server := httptest.NewServer(New(repo))
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	// application names may contain dashes
+	status, body := get("/my-app-default.properties")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "greeting: hi\n", body)
+
+	status, body = get("/master/my-app-default.json")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"greeting": "hi"}`, body)
+
+	status, body = get("/my-app/default")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"label":"master"`)
+
+	status, _ = get("/master/myapp.yml")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = get("/a/b/c/d")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = get("/my-app/default/-x")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	resp, err := http.Post(server.URL+"/my-app/default", "text/plain", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"github.com/ContainX/go-utils/envsubst"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Resolver returns the value of a variable referenced by the remote configuration
+// and whether it is defined
+type Resolver func(name string) (string, bool)
+
+// Substitution controls how variables within the remote configuration are resolved
+// by FetchWithSubstitution, FetchAsJSON, FetchAsYAML and FetchAsProperties.
+type Substitution struct {
+	// Disabled returns the remote configuration without substituting variables.
+	Disabled bool `json:"disabled"`
+
+	// Strict fails the fetch with an UndefinedVariablesError when a variable has no
+	// value rather than replacing it with an empty string.
+	Strict bool `json:"strict"`
+
+	// Resolver looks up variable values (default EnvResolver).
+	Resolver Resolver `json:"-"`
+}
+
+// UndefinedVariablesError is returned in strict mode listing every variable which
+// could not be resolved
+type UndefinedVariablesError struct {
+	Names []string
+}
+
+func (e *UndefinedVariablesError) Error() string {
+	return "Undefined variables: " + strings.Join(e.Names, ", ")
+}
+
+// EnvResolver resolves variables from the process environment
+func EnvResolver(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
+
+// MapResolver resolves variables from m
+func MapResolver(m map[string]string) Resolver {
+	return func(name string) (string, bool) {
+		v, ok := m[name]
+		return v, ok
+	}
+}
+
+// substitute replaces the variables within content according to the bootstrap
+// substitution settings
+func (c *client) substitute(content string) (string, error) {
+	s := c.bootstrap.Substitution
+	if s.Disabled {
+		return content, nil
+	}
+
+	resolve := s.Resolver
+	if resolve == nil {
+		resolve = EnvResolver
+	}
+
+	undefined := map[string]bool{}
+	content = envsubst.Substitute(strings.NewReader(content), false, func(name string) string {
+		v, ok := resolve(name)
+		if !ok {
+			undefined[name] = true
+		}
+		return v
+	})
+
+	if s.Strict && len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+		for n := range undefined {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", &UndefinedVariablesError{Names: names}
+	}
+	return content, nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const substituteYAML = "url: jdbc:mysql://${DB_HOST}:${DB_PORT}/app\nuser: ${DB_USER}\n"
+
+func TestSubstituteWithResolver(t *testing.T) {
+	server := startServer(substituteYAML)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL, Substitution: Substitution{
+		Resolver: MapResolver(map[string]string{"DB_HOST": "db", "DB_PORT": "3306", "DB_USER": "app"}),
+	}})
+
+	yml, err := cfg.FetchAsYAML()
+	assert.NoError(t, err)
+	assert.Equal(t, "url: jdbc:mysql://db:3306/app\nuser: app\n", yml)
+}
+
+func TestSubstituteStrict(t *testing.T) {
+	server := startServer(substituteYAML)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL, Substitution: Substitution{
+		Strict:   true,
+		Resolver: MapResolver(map[string]string{"DB_HOST": "db"}),
+	}})
+
+	_, err := cfg.FetchAsYAML()
+	if assert.Error(t, err) {
+		assert.Equal(t, []string{"DB_PORT", "DB_USER"}, err.(*UndefinedVariablesError).Names)
+	}
+
+	target := map[string]interface{}{}
+	assert.Error(t, cfg.FetchWithSubstitution(&target))
+}
+
+func TestSubstituteDisabled(t *testing.T) {
+	server := startServer(substituteYAML)
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL, Substitution: Substitution{Disabled: true, Strict: true}})
+
+	yml, err := cfg.FetchAsYAML()
+	assert.NoError(t, err)
+	assert.Equal(t, substituteYAML, yml)
+}
@@ -0,0 +1,292 @@
+// Package template renders text/template files from Spring Cloud Config
+// properties, similar to consul-template.  Outputs are written atomically and,
+// when watched, re-rendered with an optional reload command whenever the
+// configuration changes.
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/ContainX/go-springcloud/discovery/eureka"
+	"github.com/ContainX/go-springcloud/discovery/eureka/model"
+	"github.com/ContainX/go-utils/logger"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	gotemplate "text/template"
+	"time"
+)
+
+const (
+	// PermsDefault is the file mode of rendered files when none is specified
+	PermsDefault os.FileMode = 0644
+	// CommandTimeoutDefault bounds the execution of a reload command
+	CommandTimeoutDefault = 30 * time.Second
+)
+
+var (
+	SourceNotDeclaredErr      = errors.New("Template source must be declared")
+	DestinationNotDeclaredErr = errors.New("Template destination must be declared")
+	NoServicesClientErr       = errors.New("Service lookups require a Eureka client")
+)
+
+// LoggerName is the name of the logger used by the package
+const LoggerName = "config.template"
+
+var log = logger.GetLogger(LoggerName)
+
+func init() {
+	config.RegisterLoggers(LoggerName)
+}
+
+// Template describes a file to render from the configuration
+type Template struct {
+	// Source is the path to the text/template file.
+	Source string `json:"source"`
+
+	// Destination is the path the rendered output is written to.
+	Destination string `json:"destination"`
+
+	// Perms is the file mode of Destination (default 0644).
+	Perms os.FileMode `json:"perms"`
+
+	// Command is run with "sh -c" after Destination has changed.
+	Command string `json:"command,omitempty"`
+
+	// CommandTimeout bounds the execution of Command (default 30s).
+	CommandTimeout time.Duration `json:"commandTimeout,omitempty"`
+}
+
+// Renderer renders a set of templates using the properties of a config client
+type Renderer struct {
+	client    config.ConfigClient
+	services  eureka.EurekaClient
+	templates []*Template
+}
+
+// New creates a renderer for templates using client.  services is used for the
+// "service" template function and may be nil if service lookups are not required
+func New(client config.ConfigClient, services eureka.EurekaClient, templates ...*Template) (*Renderer, error) {
+	for _, t := range templates {
+		if t.Source == "" {
+			return nil, SourceNotDeclaredErr
+		}
+		if t.Destination == "" {
+			return nil, DestinationNotDeclaredErr
+		}
+		if t.Perms == 0 {
+			t.Perms = PermsDefault
+		}
+		if t.CommandTimeout == 0 {
+			t.CommandTimeout = CommandTimeoutDefault
+		}
+	}
+	return &Renderer{client: client, services: services, templates: templates}, nil
+}
+
+// Render refreshes the configuration and renders every template.  Reload commands
+// are not executed.  The templates whose destination changed are returned
+func (r *Renderer) Render() ([]*Template, error) {
+	if _, err := r.client.Refresh(); err != nil {
+		return nil, err
+	}
+	return r.renderAll(r.client.Properties())
+}
+
+// Watch renders the templates and then polls the configuration every interval,
+// re-rendering and running reload commands when the properties change, until stop
+// is closed
+func (r *Renderer) Watch(interval time.Duration, stop <-chan struct{}) {
+	r.client.OnRefresh(func(old, new config.Properties) {
+		changed, err := r.renderAll(new)
+		if err != nil {
+			log.Errorf("Error rendering templates: %s", err.Error())
+		}
+		if old == nil {
+			return
+		}
+		for _, t := range changed {
+			if err := t.runCommand(); err != nil {
+				log.Errorf("Error running command for %s: %s", t.Destination, err.Error())
+			}
+		}
+	})
+	r.client.Watch(interval, stop)
+}
+
+// renderAll renders every template, continuing past failures.  The first error
+// is returned along with the templates that changed
+func (r *Renderer) renderAll(p config.Properties) ([]*Template, error) {
+	var first error
+	changed := []*Template{}
+	for _, t := range r.templates {
+		ok, err := r.render(t, p)
+		if err != nil {
+			log.Errorf("Error rendering %s: %s", t.Source, err.Error())
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		if ok {
+			changed = append(changed, t)
+		}
+	}
+	return changed, first
+}
+
+// render executes t against p and writes the result if it differs from the
+// current destination contents
+func (r *Renderer) render(t *Template, p config.Properties) (bool, error) {
+	tmpl, err := gotemplate.New(filepath.Base(t.Source)).Funcs(r.funcMap(p)).ParseFiles(t.Source)
+	if err != nil {
+		return false, err
+	}
+
+	b := &bytes.Buffer{}
+	if err := tmpl.Execute(b, p); err != nil {
+		return false, err
+	}
+
+	if existing, err := ioutil.ReadFile(t.Destination); err == nil && bytes.Equal(existing, b.Bytes()) {
+		return false, nil
+	}
+	if err := writeAtomic(t.Destination, b.Bytes(), t.Perms); err != nil {
+		return false, err
+	}
+	log.Infof("Rendered %s", t.Destination)
+	return true, nil
+}
+
+func (r *Renderer) funcMap(p config.Properties) gotemplate.FuncMap {
+	return gotemplate.FuncMap{
+		"property": func(key string) (string, error) {
+			if v, ok := p[key]; ok {
+				return v, nil
+			}
+			return "", fmt.Errorf("property %q is not defined", key)
+		},
+		"propertyOrDefault": func(key, def string) string {
+			if v, ok := p[key]; ok && v != "" {
+				return v
+			}
+			return def
+		},
+		"properties": func(prefix string) map[string]string {
+			m := map[string]string{}
+			for k, v := range p {
+				if prefix == "" {
+					m[k] = v
+				} else if strings.HasPrefix(k, prefix+".") {
+					m[strings.TrimPrefix(k, prefix+".")] = v
+				}
+			}
+			return m
+		},
+		"keys": func(m map[string]string) []string {
+			return config.Properties(m).Keys()
+		},
+		"default": func(def string, value interface{}) interface{} {
+			if value == nil || value == "" {
+				return def
+			}
+			return value
+		},
+		"env": os.Getenv,
+		"service": func(name string) ([]*model.Instance, error) {
+			return r.service(name)
+		},
+	}
+}
+
+// service returns the UP instances of the named application sorted by instance id
+func (r *Renderer) service(name string) ([]*model.Instance, error) {
+	if r.services == nil {
+		return nil, NoServicesClientErr
+	}
+	app, err := r.services.GetApplication(name)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []*model.Instance{}
+	if app == nil {
+		return instances, nil
+	}
+	for _, i := range app.Instances {
+		if i.Status == model.UP {
+			instances = append(instances, i)
+		}
+	}
+	sort.Slice(instances, func(a, b int) bool {
+		return instances[a].InstanceId < instances[b].InstanceId
+	})
+	return instances, nil
+}
+
+// runCommand executes the reload command, if any, bounded by CommandTimeout.  The
+// command runs in its own process group so any processes it starts are killed
+// along with it on timeout
+func (t *Template) runCommand() error {
+	if t.Command == "" {
+		return nil
+	}
+	log.Infof("Running command: %s", t.Command)
+	cmd := exec.Command("sh", "-c", t.Command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(t.CommandTimeout):
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+		return fmt.Errorf("command timed out after %s", t.CommandTimeout)
+	}
+}
+
+// writeAtomic writes data to a temporary file in the same directory as filename
+// and renames it into place so readers never observe a partial file
+func writeAtomic(filename string, data []byte, perms os.FileMode) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), perms); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
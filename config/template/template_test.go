@@ -0,0 +1,161 @@
+package template
+
+import (
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/ContainX/go-springcloud/discovery/eureka/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const expectedOutput = `user=test
+timeout=30s
+pool.max=10
+pool.min=1
+server=10.0.0.1:8080
+`
+
+type fakeEureka struct{}
+
+func (f *fakeEureka) Register(await bool) error                               { return nil }
+func (f *fakeEureka) Unregister()                                             {}
+func (f *fakeEureka) GetInstance(name, id string) (*model.Instance, error)    { return nil, nil }
+func (f *fakeEureka) GetCurrentInstance() (*model.Instance, error)            { return nil, nil }
+func (f *fakeEureka) GetApplications() (map[string]*model.Application, error) { return nil, nil }
+func (f *fakeEureka) GetApplication(name string) (*model.Application, error) {
+	return &model.Application{Name: name, Instances: []*model.Instance{
+		{InstanceId: "b", IpAddr: "10.0.0.2", Port: model.Port{Number: 8080}, Status: model.DOWN},
+		{InstanceId: "a", IpAddr: "10.0.0.1", Port: model.Port{Number: 8080}, Status: model.UP},
+	}}, nil
+}
+
+func TestRender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("datasource.user:  test\npool.min:  1\npool.max:  10\npoolsize:  99\n"))
+	}))
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "template")
+	defer os.RemoveAll(dir)
+
+	client, _ := config.New(config.Bootstrap{Name: "myapp", URI: server.URL})
+	dest := filepath.Join(dir, "out", "app.conf")
+	r, err := New(client, &fakeEureka{}, &Template{Source: "testdata/app.conf.tmpl", Destination: dest, Perms: 0600})
+	assert.NoError(t, err)
+
+	changed, err := r.Render()
+	assert.NoError(t, err)
+	assert.Len(t, changed, 1)
+
+	content, _ := ioutil.ReadFile(dest)
+	assert.Equal(t, expectedOutput, string(content))
+	info, _ := os.Stat(dest)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	changed, err = r.Render()
+	assert.NoError(t, err)
+	assert.Empty(t, changed)
+}
+
+func TestNewRequiresPaths(t *testing.T) {
+	_, err := New(nil, nil, &Template{Destination: "out"})
+	assert.Equal(t, SourceNotDeclaredErr, err)
+	_, err = New(nil, nil, &Template{Source: "in"})
+	assert.Equal(t, DestinationNotDeclaredErr, err)
+}
+
+func TestRunCommand(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "template")
+	defer os.RemoveAll(dir)
+
+	marker := filepath.Join(dir, "reloaded")
+	tmpl := &Template{Command: "touch " + marker, CommandTimeout: CommandTimeoutDefault}
+	assert.NoError(t, tmpl.runCommand())
+	_, err := os.Stat(marker)
+	assert.NoError(t, err)
+}
+
+func TestRunCommandTimeoutKillsChildren(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	tmpl := &Template{Command: "sleep 30 & echo $! > " + pidFile + "; wait", CommandTimeout: 200 * time.Millisecond}
+	assert.Error(t, tmpl.runCommand())
+
+	b, err := ioutil.ReadFile(pidFile)
+	assert.NoError(t, err)
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	assert.True(t, pid > 0)
+
+	// the background sleep is gone, or only awaiting reaping by its new parent
+	for i := 0; i < 50 && alive(pid); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.False(t, alive(pid), "child process %d outlived the timeout", pid)
+}
+
+// alive returns true if pid is running and not a zombie
+func alive(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	status, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	return err != nil || !strings.Contains(string(status), ") Z ")
+}
+
+func TestWatch(t *testing.T) {
+	body := atomic.Value{}
+	body.Store("datasource.user:  test\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "user.tmpl")
+	dest := filepath.Join(dir, "user.conf")
+	marker := filepath.Join(dir, "reloaded")
+	ioutil.WriteFile(src, []byte(`user={{ property "datasource.user" }}`), 0644)
+
+	client, _ := config.New(config.Bootstrap{Name: "myapp", URI: server.URL})
+	r, err := New(client, nil, &Template{Source: src, Destination: dest, Command: "echo -n $(cat " + dest + ") > " + marker})
+	assert.NoError(t, err)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		r.Watch(10*time.Millisecond, stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	// the initial render does not run the command
+	assert.Equal(t, "user=test", waitForFile(t, dest, "user=test"))
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err))
+
+	body.Store("datasource.user:  changed\n")
+	assert.Equal(t, "user=changed", waitForFile(t, marker, "user=changed"))
+}
+
+// waitForFile returns the content of filename once it equals expected, or the last
+// content read after a timeout
+func waitForFile(t *testing.T, filename, expected string) string {
+	content := ""
+	for i := 0; i < 200 && content != expected; i++ {
+		b, _ := ioutil.ReadFile(filename)
+		content = string(b)
+		time.Sleep(10 * time.Millisecond)
+	}
+	return content
+}
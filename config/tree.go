@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxListIndex is the largest list index accepted in a property key.  Lists are
+// allocated up to their highest index so larger indexes are rejected rather than
+// allocating memory for elements which are not present
+const MaxListIndex = 65535
+
+// segment is a single step of a flattened property key: a map key or a list index
+type segment struct {
+	key   string
+	index int
+	list  bool
+}
+
+// Unflatten reconstructs nested maps and slices from flattened property keys.
+// Dotted keys become nested maps (datasource.user), indexed keys become slices
+// (servers[0].host) and bracketed keys allow dots within a map key
+// (routes[/api/v1.0].url).  Values remain strings.  An error is returned if a key
+// is used both as a value and as a parent of other keys
+func Unflatten(m map[string]string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		values[k] = v
+	}
+	return UnflattenValues(values)
+}
+
+// UnflattenValues is Unflatten for properties holding values of any type, such as
+// the decoded values of a property source
+func UnflattenValues(m map[string]interface{}) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := map[string]interface{}{}
+	for _, k := range keys {
+		segments, err := parseKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if err := insert(root, segments, m[k], k); err != nil {
+			return nil, err
+		}
+	}
+	return compact(root).(map[string]interface{}), nil
+}
+
+// Flatten is the inverse of Unflatten converting nested maps and slices, such as
+// a decoded YAML or JSON document, into flattened property keys
+func Flatten(tree map[string]interface{}) map[string]string {
+	m := map[string]string{}
+	for k, v := range FlattenValues(tree) {
+		m[k] = formatScalar(v)
+	}
+	return m
+}
+
+// FlattenValues is Flatten keeping the decoded values instead of converting them
+// into strings
+func FlattenValues(tree map[string]interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	flatten(m, "", tree)
+	return m
+}
+
+func (c *client) FetchAsTree() (map[string]interface{}, error) {
+	m, err := c.FetchAsMap()
+	if err != nil {
+		return nil, err
+	}
+	return Unflatten(m)
+}
+
+// parseKey splits a flattened key into its segments
+func parseKey(key string) ([]segment, error) {
+	segments := []segment{}
+	for i := 0; i < len(key); {
+		switch key[i] {
+		case '.':
+			i++
+		case '[':
+			end := strings.IndexByte(key[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated [ in property key %q", key)
+			}
+			inner := key[i+1 : i+end]
+			if n, err := strconv.Atoi(inner); err == nil && n >= 0 {
+				if n > MaxListIndex {
+					return nil, fmt.Errorf("List index %d in property key %q exceeds %d", n, key, MaxListIndex)
+				}
+				segments = append(segments, segment{index: n, list: true})
+			} else {
+				segments = append(segments, segment{key: inner})
+			}
+			i += end + 1
+		default:
+			end := strings.IndexAny(key[i:], ".[")
+			if end < 0 {
+				end = len(key) - i
+			}
+			segments = append(segments, segment{key: key[i : i+end]})
+			i += end
+		}
+	}
+	if len(segments) == 0 || segments[0].list {
+		return nil, fmt.Errorf("Invalid property key %q", key)
+	}
+	return segments, nil
+}
+
+// insert places value within node following segments.  Lists are built as maps
+// keyed by index and converted to slices by compact
+func insert(node map[string]interface{}, segments []segment, value interface{}, key string) error {
+	for i, s := range segments {
+		k := s.key
+		if s.list {
+			k = strconv.Itoa(s.index)
+			if node[listMarker] == nil && len(node) > 0 {
+				return fmt.Errorf("Property %q mixes list and map values", key)
+			}
+			node[listMarker] = true
+		} else if node[listMarker] != nil {
+			return fmt.Errorf("Property %q mixes list and map values", key)
+		}
+
+		if i == len(segments)-1 {
+			if _, exists := node[k]; exists {
+				return fmt.Errorf("Property %q conflicts with another property", key)
+			}
+			node[k] = value
+			return nil
+		}
+
+		child, exists := node[k]
+		if !exists {
+			child = map[string]interface{}{}
+			node[k] = child
+		}
+		next, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Property %q conflicts with another property", key)
+		}
+		node = next
+	}
+	return nil
+}
+
+// listMarker flags an intermediate map as holding list elements
+const listMarker = "\x00list"
+
+// compact converts the intermediate list maps built by insert into slices.  Missing
+// indexes are left nil
+func compact(node interface{}) interface{} {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return node
+	}
+
+	if m[listMarker] == nil {
+		for k, v := range m {
+			m[k] = compact(v)
+		}
+		return m
+	}
+
+	size := 0
+	for k := range m {
+		if n, err := strconv.Atoi(k); err == nil && n+1 > size {
+			size = n + 1
+		}
+	}
+	list := make([]interface{}, size)
+	for k, v := range m {
+		if n, err := strconv.Atoi(k); err == nil {
+			list[n] = compact(v)
+		}
+	}
+	return list
+}
+
+func flatten(m map[string]interface{}, prefix string, node interface{}) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			flatten(m, joinKey(prefix, k), v[k])
+		}
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for k, child := range v {
+			converted[fmt.Sprint(k)] = child
+		}
+		flatten(m, prefix, converted)
+	case []interface{}:
+		for i, child := range v {
+			flatten(m, fmt.Sprintf("%s[%d]", prefix, i), child)
+		}
+	default:
+		m[prefix] = v
+	}
+}
+
+// formatScalar converts a decoded JSON or YAML value into its property form
+func formatScalar(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// joinKey appends k to prefix, bracketing keys which contain dots or brackets
+func joinKey(prefix, k string) string {
+	if strings.ContainsAny(k, ".[]") {
+		return prefix + "[" + k + "]"
+	}
+	if prefix == "" {
+		return k
+	}
+	return prefix + "." + k
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var flatProps = map[string]string{
+	"foo":                     "bar",
+	"datasource.user":         "test",
+	"servers[0].host":         "a",
+	"servers[1].host":         "b",
+	"servers[1].ports[0]":     "80",
+	"routes[/api/v1.0].url":   "http://api",
+	"logging.level[com.acme]": "DEBUG",
+}
+
+var treeProps = map[string]interface{}{
+	"foo":        "bar",
+	"datasource": map[string]interface{}{"user": "test"},
+	"servers": []interface{}{
+		map[string]interface{}{"host": "a"},
+		map[string]interface{}{"host": "b", "ports": []interface{}{"80"}},
+	},
+	"routes":  map[string]interface{}{"/api/v1.0": map[string]interface{}{"url": "http://api"}},
+	"logging": map[string]interface{}{"level": map[string]interface{}{"com.acme": "DEBUG"}},
+}
+
+func TestUnflatten(t *testing.T) {
+	tree, err := Unflatten(flatProps)
+	assert.NoError(t, err)
+	assert.Equal(t, treeProps, tree)
+}
+
+func TestFlatten(t *testing.T) {
+	assert.Equal(t, flatProps, Flatten(treeProps))
+	assert.Equal(t, map[string]string{"a.b": "1.5", "a.c": "true", "a.d": ""},
+		Flatten(map[string]interface{}{"a": map[string]interface{}{"b": 1.5, "c": true, "d": nil}}))
+}
+
+func TestFlattenValues(t *testing.T) {
+	tree := map[string]interface{}{"server": map[interface{}]interface{}{"port": 8080, "tls": true}, "hosts": []interface{}{"a", 1.5}}
+	flat := map[string]interface{}{"server.port": 8080, "server.tls": true, "hosts[0]": "a", "hosts[1]": 1.5}
+	assert.Equal(t, flat, FlattenValues(tree))
+
+	unflat, err := UnflattenValues(flat)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"server": map[string]interface{}{"port": 8080, "tls": true}, "hosts": []interface{}{"a", 1.5}}, unflat)
+}
+
+func TestUnflattenErrors(t *testing.T) {
+	_, err := Unflatten(map[string]string{"a": "1", "a.b": "2"})
+	assert.Error(t, err)
+	_, err = Unflatten(map[string]string{"a[0]": "1", "a.b": "2"})
+	assert.Error(t, err)
+	_, err = Unflatten(map[string]string{"a[0": "1"})
+	assert.Error(t, err)
+	_, err = Unflatten(map[string]string{"[0]": "1"})
+	assert.Error(t, err)
+	_, err = Unflatten(map[string]string{"a[1000000000]": "1"})
+	assert.Error(t, err)
+}
+
+func TestConfigAsTree(t *testing.T) {
+	server := startServer("datasource.user:  test\nservers[0].host:  a\n")
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	tree, err := cfg.FetchAsTree()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", tree["servers"].([]interface{})[0].(map[string]interface{})["host"])
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/xeipuuv/gojsonschema"
+	"path/filepath"
+	"strings"
+)
+
+const rootField = "(root)"
+
+// ValidationError is returned when the fetched configuration does not conform to
+// the bootstrap schema.  Each error is qualified by the property path (ex. datasource.port)
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return "Configuration failed schema validation: " + strings.Join(e.Errors, "; ")
+}
+
+// loadSchema compiles the embedded schema or schema file declared in the bootstrap
+func (c *client) loadSchema() error {
+	var loader gojsonschema.JSONLoader
+	switch {
+	case len(c.bootstrap.Schema) > 0:
+		loader = gojsonschema.NewBytesLoader(c.bootstrap.Schema)
+	case c.bootstrap.SchemaFile != "":
+		path, err := filepath.Abs(c.bootstrap.SchemaFile)
+		if err != nil {
+			return err
+		}
+		loader = gojsonschema.NewReferenceLoader("file://" + filepath.ToSlash(path))
+	default:
+		return nil
+	}
+
+	schema, err := gojsonschema.NewSchema(loader)
+	if err != nil {
+		return fmt.Errorf("Invalid configuration schema: %s", err.Error())
+	}
+	c.schema = schema
+	return nil
+}
+
+// validate checks the JSON document content against the schema if one is declared
+func (c *client) validate(content string) error {
+	if c.schema == nil {
+		return nil
+	}
+
+	result, err := c.schema.Validate(gojsonschema.NewStringLoader(content))
+	if err != nil {
+		return err
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	ve := &ValidationError{}
+	for _, re := range result.Errors() {
+		field := re.Field()
+		if field == rootField {
+			field = "$"
+		}
+		ve.Errors = append(ve.Errors, field+": "+re.Description())
+	}
+	return ve
+}
+
+// fetchValidated fetches the JSON form of the configuration, validates it and
+// returns the properties flattened from the same document.  This is used to guard
+// refreshes so invalid configuration is never applied
+func (c *client) fetchValidated() (Properties, error) {
+	content, err := c.fetch(extJSON)
+	if err != nil {
+		return nil, err
+	}
+	if content, err = c.overlayContent(extJSON, content); err != nil {
+		return nil, err
+	}
+	if err = c.validate(content); err != nil {
+		return nil, err
+	}
+
+	tree := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(content), &tree); err != nil {
+		return nil, err
+	}
+	return Flatten(tree), nil
+}
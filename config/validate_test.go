@@ -0,0 +1,69 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	FileSchema  = "testdata/schema.json"
+	invalidJSON = `{"foo": "baz", "datasource": {"port": "3306"}}`
+)
+
+func TestFetchValidatesSchema(t *testing.T) {
+	server := startServer(invalidJSON)
+	defer server.Close()
+
+	cfg, err := New(Bootstrap{Name: "myapp", URI: server.URL, SchemaFile: FileSchema})
+	assert.NoError(t, err)
+
+	ts := &testStruct{}
+	err = cfg.Fetch(ts)
+	if assert.IsType(t, &ValidationError{}, err) {
+		errs := err.(*ValidationError).Errors
+		assert.Len(t, errs, 2)
+		assert.True(t, strings.HasPrefix(errs[0], "datasource: "), errs[0])
+		assert.True(t, strings.HasPrefix(errs[1], "datasource.port: "), errs[1])
+	}
+	assert.Equal(t, "", ts.Foo)
+}
+
+func TestRefreshKeepsLastGoodConfig(t *testing.T) {
+	valid := atomic.Bool{}
+	valid.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, ".json") && valid.Load():
+			w.Write([]byte(`{"foo": "bar", "datasource": {"host": "db"}}`))
+		case strings.HasSuffix(r.URL.Path, ".json"):
+			w.Write([]byte(invalidJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	schema, _ := ioutil.ReadFile(FileSchema)
+	cfg, err := New(Bootstrap{Name: "myapp", URI: server.URL, Schema: schema})
+	assert.NoError(t, err)
+
+	_, err = cfg.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, Properties{"foo": "bar", "datasource.host": "db"}, cfg.Properties())
+
+	valid.Store(false)
+	changed, err := cfg.Refresh()
+	assert.False(t, changed)
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, "bar", cfg.Properties()["foo"])
+}
+
+func TestInvalidSchema(t *testing.T) {
+	_, err := New(Bootstrap{Name: "myapp", Schema: []byte(`{"type": 1}`)})
+	assert.Error(t, err)
+}
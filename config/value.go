@@ -0,0 +1,81 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Value holds the remote configuration bound into T.  Reads are lock free; each
+// refresh of the client which changes the configuration binds a new T and swaps it
+// in atomically before notifying subscribers.
+type Value[T any] struct {
+	client      ConfigClient
+	current     atomic.Pointer[T]
+	mu          sync.Mutex
+	subscribers []func(old, new *T)
+}
+
+// NewValue creates a Value from client, binding the properties applied by the
+// client, or the pinned properties while the client is pinned, into T.  The client
+// is refreshed first if it has not fetched the configuration yet.  The value is
+// updated whenever a refresh changes T
+func NewValue[T any](client ConfigClient) (*Value[T], error) {
+	v := &Value[T]{client: client}
+	t, err := v.bind()
+	if err != nil {
+		return nil, err
+	}
+	v.current.Store(t)
+	client.OnRefresh(v.refresh)
+	return v, nil
+}
+
+// Load returns the current snapshot.  Snapshots are shared between readers and must
+// be treated as read-only
+func (v *Value[T]) Load() *T {
+	return v.current.Load()
+}
+
+// Subscribe registers fn to be called, in registration order, after a new snapshot
+// has been swapped in
+func (v *Value[T]) Subscribe(fn func(old, new *T)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.subscribers = append(v.subscribers, fn)
+}
+
+// bind binds the properties applied by the client as refresh does, so the first
+// and later snapshots are bound the same way
+func (v *Value[T]) bind() (*T, error) {
+	if v.client.Properties() == nil {
+		if _, err := v.client.Refresh(); err != nil {
+			return nil, err
+		}
+	}
+	t := new(T)
+	if err := bindProperties(v.client.Properties(), t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// refresh binds the refreshed properties, keeping the current snapshot if they
+// cannot be bound or the bound value is unchanged
+func (v *Value[T]) refresh(_, p Properties) {
+	t := new(T)
+	if err := bindProperties(p, t); err != nil {
+		log.Errorf("Error binding refreshed configuration: %s", err.Error())
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if reflect.DeepEqual(v.current.Load(), t) {
+		return
+	}
+	old := v.current.Swap(t)
+	for _, fn := range v.subscribers {
+		fn(old, t)
+	}
+}
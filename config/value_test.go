@@ -0,0 +1,113 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type typedStruct struct {
+	Foo  string `json:"foo"`
+	Pool struct {
+		Max     int      `json:"max"`
+		Enabled bool     `json:"enabled"`
+		Ratio   float64  `json:"ratio"`
+		Hosts   []string `json:"hosts"`
+	} `json:"pool"`
+	Timeout Duration `json:"timeout"`
+}
+
+func TestValue(t *testing.T) {
+	body := atomic.Value{}
+	body.Store("foo:  bar\n")
+	requests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	v, err := NewValue[testStruct](cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", v.Load().Foo)
+	assert.Equal(t, "bar", cfg.Properties()["foo"], "the client is refreshed to bind its properties")
+
+	notified := []string{}
+	v.Subscribe(func(old, new *testStruct) {
+		notified = append(notified, old.Foo, new.Foo)
+	})
+
+	cfg.Refresh()
+	first := v.Load()
+
+	// properties which are not bound do not change the value
+	body.Store("foo:  bar\nother:  1\n")
+	cfg.Refresh()
+	assert.Same(t, first, v.Load())
+
+	body.Store("foo:  baz\n")
+	atomic.StoreInt32(&requests, 0)
+	cfg.Refresh()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "refresh binds the refreshed properties")
+	assert.Equal(t, "baz", v.Load().Foo)
+	assert.Equal(t, "bar", first.Foo)
+	assert.Equal(t, []string{"bar", "baz"}, notified)
+}
+
+func TestOnRefreshRecoversPanics(t *testing.T) {
+	server := startServer("foo:  bar\n")
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	notified := false
+	cfg.OnRefresh(func(_, _ Properties) { panic("boom") })
+	cfg.OnRefresh(func(_, _ Properties) { notified = true })
+
+	changed, err := cfg.Refresh()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, notified)
+}
+
+func TestValuePinned(t *testing.T) {
+	body := atomic.Value{}
+	body.Store("foo:  bar\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	cfg, _ := New(Bootstrap{Name: "myapp", URI: server.URL})
+	cfg.Refresh()
+	assert.NoError(t, cfg.Pin(cfg.History()[0].Version))
+
+	body.Store("foo:  baz\n")
+	v, err := NewValue[testStruct](cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", v.Load().Foo)
+}
+
+func TestBindProperties(t *testing.T) {
+	ts := &typedStruct{}
+	err := bindProperties(Properties{
+		"foo":           "123",
+		"pool.max":      "10",
+		"pool.enabled":  "true",
+		"pool.ratio":    "0.5",
+		"pool.hosts[0]": "a",
+		"pool.hosts[1]": "2",
+		"timeout":       "30s",
+	}, ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "123", ts.Foo)
+	assert.Equal(t, 10, ts.Pool.Max)
+	assert.True(t, ts.Pool.Enabled)
+	assert.Equal(t, 0.5, ts.Pool.Ratio)
+	assert.Equal(t, []string{"a", "2"}, ts.Pool.Hosts)
+	assert.Equal(t, "30s", ts.Timeout.String())
+
+	assert.Error(t, bindProperties(Properties{"pool.max": "ten"}, ts))
+}
@@ -0,0 +1,265 @@
+// Package viperremote exposes Spring Cloud Config as a Viper remote provider.
+// Importing the package registers the "springcloud" provider:
+//
+//	import _ "github.com/ContainX/go-springcloud/config/viperremote"
+//
+//	viper.AddRemoteProvider("springcloud", "http://localhost:8888", "myapp/prod/master")
+//	viper.SetConfigType("json")
+//	err := viper.ReadRemoteConfig()
+//
+// The path is {name}[/{profile}[/{label}]] and credentials may be given as the
+// endpoint's userinfo.  Configuration is served as JSON so the config type must
+// be "json".  Clients needing other settings (discovery, schemas, substitution)
+// can be supplied with Register.
+package viperremote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ContainX/go-springcloud/config"
+	"github.com/ContainX/go-utils/logger"
+	"github.com/spf13/viper"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider is the name of the remote provider registered with Viper
+const Provider = "springcloud"
+
+// PollInterval is how often the configuration is refreshed for
+// WatchRemoteConfigOnChannel
+var PollInterval = 30 * time.Second
+
+// LoggerName is the name of the logger used by the package
+const LoggerName = "config.viperremote"
+
+var log = logger.GetLogger(LoggerName)
+
+var (
+	mu       sync.Mutex
+	clients  = map[string]config.ConfigClient{}
+	previous remoteConfigFactory
+)
+
+// remoteConfigFactory mirrors the unexported type of viper.RemoteConfig
+type remoteConfigFactory interface {
+	Get(rp viper.RemoteProvider) (io.Reader, error)
+	Watch(rp viper.RemoteProvider) (io.Reader, error)
+	WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool)
+}
+
+func init() {
+	config.RegisterLoggers(LoggerName)
+	Install()
+}
+
+// Install registers the provider with Viper.  It is called when the package is
+// imported and only needs to be called again when viper/remote, which replaces
+// viper.RemoteConfig, is imported as well.  Other providers are delegated to the
+// remote config which was installed before
+func Install() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := viper.RemoteConfig.(remoteConfig); ok {
+		return
+	}
+	previous = viper.RemoteConfig
+	viper.RemoteConfig = remoteConfig{}
+
+	for _, p := range viper.SupportedRemoteProviders {
+		if p == Provider {
+			return
+		}
+	}
+	viper.SupportedRemoteProviders = append(viper.SupportedRemoteProviders, Provider)
+}
+
+// Register uses client for the remote provider added with endpoint and path
+// instead of creating one from them
+func Register(endpoint, path string, client config.ConfigClient) {
+	mu.Lock()
+	defer mu.Unlock()
+	clients[endpoint+"|"+path] = client
+}
+
+type remoteConfig struct{}
+
+// delegate returns the remote config handling providers other than Provider
+func delegate(rp viper.RemoteProvider) (remoteConfigFactory, error) {
+	if previous == nil {
+		return nil, fmt.Errorf("Unsupported remote provider %q, import viper/remote before calling Install", rp.Provider())
+	}
+	return previous, nil
+}
+
+// Get refreshes the configuration and returns the applied properties
+func (r remoteConfig) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	if rp.Provider() != Provider {
+		d, err := delegate(rp)
+		if err != nil {
+			return nil, err
+		}
+		return d.Get(rp)
+	}
+
+	c, err := clientFor(rp)
+	if err != nil {
+		return nil, err
+	}
+	return reader(refreshed(c))
+}
+
+// Watch refreshes the configuration and returns the latest properties
+func (r remoteConfig) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	if rp.Provider() != Provider {
+		d, err := delegate(rp)
+		if err != nil {
+			return nil, err
+		}
+		return d.Watch(rp)
+	}
+
+	c, err := clientFor(rp)
+	if err != nil {
+		return nil, err
+	}
+	return reader(refreshed(c))
+}
+
+// WatchChannel polls the config server every PollInterval and sends the
+// configuration whenever it changes until the returned channel is closed
+func (r remoteConfig) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	responses := make(chan *viper.RemoteResponse)
+	quit := make(chan bool)
+
+	if rp.Provider() != Provider {
+		d, err := delegate(rp)
+		if err != nil {
+			log.Error(err.Error())
+			return responses, quit
+		}
+		return d.WatchChannel(rp)
+	}
+
+	c, err := clientFor(rp)
+	if err != nil {
+		log.Errorf("Error creating client for %s: %s", rp.Path(), err.Error())
+		return responses, quit
+	}
+
+	interval := PollInterval
+	go func() {
+		// the first refresh establishes the baseline the changes are detected from
+		c.Refresh()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+			}
+
+			changed, err := c.Refresh()
+			if err != nil {
+				log.Errorf("Error refreshing configuration: %s", err.Error())
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			b, err := encodeProperties(c.Properties())
+			if err != nil {
+				log.Errorf("Error encoding configuration: %s", err.Error())
+				continue
+			}
+			select {
+			case responses <- &viper.RemoteResponse{Value: b}:
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return responses, quit
+}
+
+// clientFor returns the registered client for rp, creating one on first use
+func clientFor(rp viper.RemoteProvider) (config.ConfigClient, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	key := rp.Endpoint() + "|" + rp.Path()
+	if c, ok := clients[key]; ok {
+		return c, nil
+	}
+
+	b, err := bootstrapFor(rp.Endpoint(), rp.Path())
+	if err != nil {
+		return nil, err
+	}
+	c, err := config.New(b)
+	if err != nil {
+		return nil, err
+	}
+	clients[key] = c
+	return c, nil
+}
+
+// bootstrapFor parses an endpoint and a {name}/{profile}/{label} path
+func bootstrapFor(endpoint, path string) (config.Bootstrap, error) {
+	b := config.Bootstrap{}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return b, err
+	}
+	if u.User != nil {
+		b.Username = u.User.Username()
+		b.Password, _ = u.User.Password()
+		u.User = nil
+	}
+	b.URI = u.String()
+
+	parts := strings.SplitN(strings.Trim(path, "/"), "/", 3)
+	b.Name = parts[0]
+	if len(parts) > 1 {
+		b.Profile = parts[1]
+	}
+	if len(parts) > 2 {
+		b.Label = parts[2]
+	}
+	return b, nil
+}
+
+// refreshed refreshes c and encodes the properties it applied, so a pinned client
+// serves its pinned properties and every call returns the same representation
+func refreshed(c config.ConfigClient) ([]byte, error) {
+	if _, err := c.Refresh(); err != nil {
+		return nil, err
+	}
+	return encodeProperties(c.Properties())
+}
+
+// encodeProperties encodes p as a JSON tree.  Every path encodes the applied
+// properties with it so values have the same types whichever one served them
+func encodeProperties(p config.Properties) ([]byte, error) {
+	tree, err := config.Unflatten(p)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tree)
+}
+
+func reader(b []byte, err error) (io.Reader, error) {
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
@@ -47,7 +47,10 @@ type eurekaResponse struct {
 	err    error
 }
 
-var log = logger.GetLogger("discovery")
+// LoggerName is the name of the logger used by the package
+const LoggerName = "discovery"
+
+var log = logger.GetLogger(LoggerName)
 
 func NewClient(cfg *model.EurekaConfig) EurekaClient {
 	return &eureka{config: cfg, shutdown: make(ShutdownChan, 2)}
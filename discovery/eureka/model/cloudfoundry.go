@@ -0,0 +1,57 @@
+package model
+
+import (
+	"github.com/ContainX/go-springcloud/cloudfoundry"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// eurekaPath is appended to the service registry URI as in Spring's defaultZone
+	eurekaPath = "/eureka"
+
+	envInstanceIP = "CF_INSTANCE_INTERNAL_IP"
+	envPort       = "PORT"
+)
+
+// PopulateFromCloudFoundry fills the unset service URLs from a Spring Cloud Services
+// service registry bound in VCAP_SERVICES and the unset instance settings from
+// VCAP_APPLICATION and the container's internal address, so instances register
+// for direct container-to-container access.  Read calls it when the CloudFoundry
+// setting is enabled and it does nothing outside Cloud Foundry.  The returned bool
+// indicates a bound service registry
+func (c *EurekaConfig) PopulateFromCloudFoundry() (bool, error) {
+	app, err := cloudfoundry.ReadApplication()
+	if err != nil {
+		return false, err
+	}
+	if app != nil {
+		if c.Instance.AppName == "" {
+			c.Instance.AppName = app.ApplicationName
+		}
+		if c.Instance.IpAddress == "" {
+			c.Instance.IpAddress = os.Getenv(envInstanceIP)
+		}
+		if c.Instance.Port == 0 {
+			c.Instance.Port, _ = strconv.Atoi(os.Getenv(envPort))
+		}
+	}
+
+	services, err := cloudfoundry.ReadServices()
+	if err != nil {
+		return false, err
+	}
+	svc := services.ServiceRegistry()
+	if svc == nil {
+		return false, nil
+	}
+
+	if len(c.Client.ServiceUrls) == 0 {
+		if uri := svc.Credential(cloudfoundry.CredentialURI); uri != "" {
+			c.Client.ServiceUrls = []string{strings.TrimSuffix(uri, "/") + eurekaPath}
+		}
+	}
+	log.Infof("Using service registry %s bound to %s", strings.Join(c.Client.ServiceUrls, ","), svc.Name)
+	return true, nil
+}
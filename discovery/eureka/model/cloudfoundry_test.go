@@ -0,0 +1,37 @@
+package model
+
+import (
+	"github.com/ContainX/go-springcloud/cloudfoundry"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPopulateFromCloudFoundry(t *testing.T) {
+	t.Setenv(cloudfoundry.EnvApplication, `{"application_name": "myapp", "application_uris": ["myapp.apps.example.com"]}`)
+	t.Setenv(cloudfoundry.EnvServices, `{"p-service-registry": [{"name": "registry", "credentials": {"uri": "https://eureka.apps.example.com/"}}]}`)
+	t.Setenv(envInstanceIP, "10.0.0.5")
+	t.Setenv(envPort, "8080")
+
+	cfg := NewConfigFromArgs("", "", 0)
+	assert.Empty(t, cfg.Client.ServiceUrls)
+
+	bound, err := cfg.PopulateFromCloudFoundry()
+	assert.NoError(t, err)
+	assert.True(t, bound)
+	assert.Equal(t, []string{"https://eureka.apps.example.com/eureka"}, cfg.Client.ServiceUrls)
+	assert.Equal(t, "myapp", cfg.Instance.AppName)
+	assert.Equal(t, "10.0.0.5", cfg.Instance.IpAddress)
+	assert.Equal(t, 8080, cfg.Instance.Port)
+
+	// declared settings are kept
+	cfg = NewConfigFromArgs("other", "1.1.1.1", 9000, TestServiceUrl)
+	_, err = cfg.PopulateFromCloudFoundry()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{TestServiceUrl}, cfg.Client.ServiceUrls)
+	assert.Equal(t, "other", cfg.Instance.AppName)
+	assert.Equal(t, 9000, cfg.Instance.Port)
+
+	t.Setenv(cloudfoundry.EnvServices, "{")
+	_, err = NewConfigFromArgs("", "", 0).PopulateFromCloudFoundry()
+	assert.Error(t, err)
+}
@@ -10,6 +10,8 @@ type EurekaConfig struct {
 	Instance EurekaInstanceConfig `json:"instance"`
 	// Client - Eureka Client Configuration
 	Client EurekaClientConfig `json:"client"`
+	// CloudFoundry - Populate unset settings from the Cloud Foundry environment in Read
+	CloudFoundry bool `json:"cloudFoundry,omitempty"`
 }
 
 type EurekaInstanceConfig struct {
@@ -39,8 +41,13 @@ func (c *EurekaConfig) PopulateDefaults() {
 	c.Client.HealthCheckEnabled = true
 }
 
-var log = logger.GetLogger("discovery.model")
+// LoggerName is the name of the logger used by the package
+const LoggerName = "discovery.model"
 
+var log = logger.GetLogger(LoggerName)
+
+// NewConfigFromArgs creates a config from the given settings.  Call
+// PopulateFromCloudFoundry on the result to fill the unset settings on Cloud Foundry
 func NewConfigFromArgs(appName, host string, port int, serviceUrls ...string) *EurekaConfig {
 	ec := &EurekaConfig{
 		Instance: EurekaInstanceConfig{
@@ -71,5 +78,11 @@ func Read(configFile string) (config EurekaConfig, err error) {
 		log.Fatalf("Error reading config: %s - %s", configFile, err.Error())
 		return config, err
 	}
+
+	if config.CloudFoundry {
+		if _, err = config.PopulateFromCloudFoundry(); err != nil {
+			return config, err
+		}
+	}
 	return config, nil
 }
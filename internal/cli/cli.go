@@ -0,0 +1,72 @@
+// Package cli holds the flag handling shared by the springcloud commands.
+package cli
+
+import (
+	"flag"
+	"github.com/ContainX/go-springcloud/config"
+	"os"
+)
+
+// EnvPassword is the environment variable holding the config server password.  It
+// is read from the environment so it never appears in the process list
+const EnvPassword = "CONFIG_SERVER_PASSWORD"
+
+// ClientFlags are the flags used to create a config client
+type ClientFlags struct {
+	File      string
+	Bootstrap config.Bootstrap
+}
+
+// AddClientFlags registers the config client flags with fs
+func AddClientFlags(fs *flag.FlagSet) *ClientFlags {
+	f := &ClientFlags{}
+	b := &f.Bootstrap
+	fs.StringVar(&f.File, "config", "", "Bootstrap file (json or yml) holding the config client settings")
+	fs.StringVar(&b.URI, "uri", "", "Config server URI (default "+config.UriDefault+")")
+	fs.StringVar(&b.Name, "name", "", "Application name to fetch configuration for")
+	fs.StringVar(&b.Profile, "profile", "", "Profile(s) to fetch, comma-separated (default "+config.ProfileDefault+")")
+	fs.StringVar(&b.Label, "label", "", "Label (branch, tag or commit) to fetch (default "+config.LabelDefault+")")
+	fs.StringVar(&b.Username, "username", "", "HTTP Basic username (password is read from "+EnvPassword+")")
+	return f
+}
+
+// NewClient creates a config client from the bootstrap file, if any, overridden by
+// the flags which were set
+func (f *ClientFlags) NewClient() (config.ConfigClient, error) {
+	b := f.Bootstrap
+	b.Password = os.Getenv(EnvPassword)
+	if f.File == "" {
+		return config.New(b)
+	}
+
+	file, err := config.ReadBootstrap(f.File)
+	if err != nil {
+		return nil, err
+	}
+	file.Name = override(file.Name, b.Name)
+	file.URI = override(file.URI, b.URI)
+	file.Profile = override(file.Profile, b.Profile)
+	file.Label = override(file.Label, b.Label)
+	file.Username = override(file.Username, b.Username)
+	file.Password = override(file.Password, b.Password)
+	file.ExplicitProfile = file.ExplicitProfile || b.ExplicitProfile
+	return config.New(*file)
+}
+
+// NewClientFor creates a config client as NewClient but fetching profile and label
+// instead.  Empty values keep the flag or bootstrap file settings.  An explicit
+// profile takes precedence over CONFIG_PROFILE
+func (f *ClientFlags) NewClientFor(profile, label string) (config.ConfigClient, error) {
+	c := *f
+	c.Bootstrap.Profile = override(f.Bootstrap.Profile, profile)
+	c.Bootstrap.Label = override(f.Bootstrap.Label, label)
+	c.Bootstrap.ExplicitProfile = profile != ""
+	return c.NewClient()
+}
+
+func override(current, value string) string {
+	if value != "" {
+		return value
+	}
+	return current
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewClientOverridesFile(t *testing.T) {
+	t.Setenv("CONFIG_PROFILE", "")
+	t.Setenv(EnvPassword, "s3cret")
+	file := filepath.Join(t.TempDir(), "bootstrap.json")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(`{"uri": "http://config:8888", "profile": "dev"}`), 0600))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := AddClientFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"-config", file, "-name", "foo", "-label", "v1"}))
+
+	// the file has no name so it is only valid with the flags applied
+	c, err := f.NewClient()
+	assert.NoError(t, err)
+	b := c.Bootstrap()
+	assert.Equal(t, "foo", b.Name)
+	assert.Equal(t, "http://config:8888", b.URI)
+	assert.Equal(t, "dev", b.Profile)
+	assert.Equal(t, "v1", b.Label)
+	assert.Equal(t, "s3cret", b.Password)
+	assert.Empty(t, f.Bootstrap.Password)
+
+	c, err = f.NewClientFor("prod", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", c.Bootstrap().Profile)
+	assert.True(t, c.Bootstrap().ExplicitProfile)
+}